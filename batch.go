@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// batchResult is the outcome of testing one file in --all mode.
+type batchResult struct {
+	Path   string
+	Passed int
+	Total  int
+	Err    error
+	// Regressions counts cases that did better in the previous run.
+	Regressions int
+}
+
+// runBatch compiles and tests every file matched by cfg.spec without the
+// TUI, then prints a summary table to out. It returns errTestsFailed when
+// any file fails.
+func runBatch(cfg appConfig, out io.Writer) error {
+	targets, err := listWatchTargets(cfg.spec, newIncludeCache())
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no matching files found for %s", cfg.spec.DisplayBase())
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Path < targets[j].Path })
+
+	opts := runOptions{
+		compileFlags: cfg.compileFlags,
+		record:       cfg.record,
+		timeLimit:    cfg.timeLimit,
+		checker:      cfg.checker,
+		noDelay:      true,
+		history:      openHistory(cfg.historyDir),
+	}
+
+	results := make([]batchResult, 0, len(targets))
+	for i, target := range targets {
+		fmt.Fprintf(out, "[%d/%d] %s\n", i+1, len(targets), target.Path)
+		result := batchResult{Path: target.Path}
+		result.Passed, result.Total, result.Err = runWorkflow(context.Background(), target.Path, opts, func(msg tea.Msg) {
+			if v, ok := msg.(historyRecordedMsg); ok {
+				result.Regressions = v.Regressions
+			}
+		})
+		results = append(results, result)
+	}
+
+	return writeBatchSummary(out, results)
+}
+
+// writeBatchSummary prints one row per file followed by the overall count,
+// returning errTestsFailed when any file failed.
+func writeBatchSummary(out io.Writer, results []batchResult) error {
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tRESULT\tCASES\tDETAILS")
+
+	failed := 0
+	for _, r := range results {
+		result, details := "PASS", ""
+		if r.Err != nil {
+			failed++
+			result = "FAIL"
+			details = shortenString(r.Err.Error(), 80)
+		}
+		if r.Regressions > 0 {
+			details = fmt.Sprintf("▲ %d regressed %s", r.Regressions, details)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\n", r.Path, result, r.Passed, r.Total, details)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	if failed > 0 {
+		fmt.Fprintf(out, "🚨 Files passed: %d/%d\n", len(results)-failed, len(results))
+		return errTestsFailed
+	}
+	fmt.Fprintf(out, "🎉 Files passed: %d/%d\n", len(results), len(results))
+	return nil
+}
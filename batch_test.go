@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteBatchSummary(t *testing.T) {
+	var out strings.Builder
+	err := writeBatchSummary(&out, []batchResult{
+		{Path: "solutions/a.cpp", Passed: 3, Total: 3},
+		{Path: "solutions/b.cpp", Passed: 1, Total: 2, Err: errors.New("case 2: expected output \"4\", got \"5\" (line 1)")},
+	})
+	if !errors.Is(err, errTestsFailed) {
+		t.Fatalf("expected errTestsFailed, got %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"solutions/a.cpp  PASS    3/3",
+		"solutions/b.cpp  FAIL    1/2    case 2: expected output",
+		"Files passed: 1/2",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary missing %q:\n%s", want, got)
+		}
+	}
+
+	out.Reset()
+	if err := writeBatchSummary(&out, []batchResult{{Path: "a.cpp", Passed: 1, Total: 1}}); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pedrohff/defi/components"
+)
+
+const benchUsageMessage = "usage: defi bench <solution> [--sizes 1e3,1e4,1e5] [--reps N] [--var n] [--gen file] [--seed N] [--time-limit D] [--csv file] [--json file] [--no-tui]"
+
+// defaultBenchSizes spans two orders of magnitude, enough to tell common
+// complexities apart without waiting on quadratic solutions for long.
+var defaultBenchSizes = []int64{1000, 2000, 5000, 10000, 20000, 50000, 100000}
+
+// defaultBenchTimeLimit stops the benchmark once a size gets this slow.
+const defaultBenchTimeLimit = 10 * time.Second
+
+// errBenchStopped is reported when a size exceeded the time limit. The
+// sizes measured before it are still worth reporting.
+var errBenchStopped = errors.New("stopped")
+
+// benchOptions configures a benchmark.
+type benchOptions struct {
+	solution string
+	// generator, when set, is run as "generator <seed> <size>"; otherwise the
+	// GEN directive is expanded with variable fixed to each size.
+	generator    string
+	variable     string
+	sizes        []int64
+	reps         int
+	seed         uint64
+	timeLimit    time.Duration
+	compileFlags []string
+}
+
+// benchPoint holds the timings of every repetition at one input size.
+type benchPoint struct {
+	Size int64
+	Wall []time.Duration
+	CPU  []time.Duration
+}
+
+// Time is the median CPU time, or wall time where CPU time is not reported.
+func (p benchPoint) Time() time.Duration {
+	if cpu := medianDuration(p.CPU); cpu > 0 {
+		return cpu
+	}
+	return medianDuration(p.Wall)
+}
+
+// complexityFit is how well t ≈ Coefficient·f(n) explains the timings.
+type complexityFit struct {
+	Name        string
+	Coefficient float64
+	// Error is the root mean square relative error of the model.
+	Error float64
+}
+
+// complexityModels are the growth rates considered when fitting.
+var complexityModels = []struct {
+	name string
+	f    func(n float64) float64
+}{
+	{"O(1)", func(float64) float64 { return 1 }},
+	{"O(log n)", func(n float64) float64 { return math.Log2(n) }},
+	{"O(n)", func(n float64) float64 { return n }},
+	{"O(n log n)", func(n float64) float64 { return n * math.Log2(n) }},
+	{"O(n²)", func(n float64) float64 { return n * n }},
+	{"O(n³)", func(n float64) float64 { return n * n * n }},
+}
+
+// benchSession holds the compiled programs of a benchmark.
+type benchSession struct {
+	dir       string
+	solution  string
+	generator string
+	spec      *genSpec
+	opts      benchOptions
+}
+
+// benchPointMsg reports the timings of one size.
+type benchPointMsg struct {
+	Point benchPoint
+}
+
+// benchDoneMsg ends a benchmark; Err explains an early stop.
+type benchDoneMsg struct {
+	Err error
+}
+
+// runBenchCommand times a solution on generated inputs of increasing size.
+func runBenchCommand(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("defi bench", flag.ContinueOnError)
+	sizesFlag := fs.String("sizes", "", "Comma-separated input sizes (default 1e3 up to 1e5)")
+	repsFlag := fs.Int("reps", 3, "Repetitions per size; the median is used")
+	varFlag := fs.String("var", "", "GEN variable set to each size (default: the first one)")
+	genFlag := fs.String("gen", "", "Generator run as \"gen <seed> <size>\" instead of the GEN directive")
+	seedFlag := fs.Uint64("seed", 1, "Seed for the generated inputs")
+	timeLimitFlag := fs.Duration("time-limit", defaultBenchTimeLimit, "Stop once a run takes longer than this")
+	compileFlagsFlag := fs.String("compile-flags", "", "Override compiler flags (space-separated)")
+	csvFlag := fs.String("csv", "", "Write every timing to this CSV file")
+	jsonFlag := fs.String("json", "", "Write the timings and fits to this JSON file")
+	noTUIFlag := fs.Bool("no-tui", false, "Print a table instead of showing the chart")
+
+	// Accept flags both before and after the solution.
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() == 0 {
+		return usageErrorf("missing solution file")
+	}
+	solution := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *repsFlag < 1 {
+		return usageErrorf("reps must be at least 1")
+	}
+
+	sizes := defaultBenchSizes
+	if *sizesFlag != "" {
+		var err error
+		if sizes, err = parseBenchSizes(*sizesFlag); err != nil {
+			return err
+		}
+	}
+
+	opts := benchOptions{
+		solution:     solution,
+		generator:    *genFlag,
+		variable:     *varFlag,
+		sizes:        sizes,
+		reps:         *repsFlag,
+		seed:         *seedFlag,
+		timeLimit:    *timeLimitFlag,
+		compileFlags: strings.Fields(*compileFlagsFlag),
+	}
+
+	var (
+		points []benchPoint
+		err    error
+	)
+	if *noTUIFlag {
+		points, err = runBenchPlain(opts, stdout)
+	} else {
+		var theme components.Theme
+		if theme, err = userTheme(); err != nil {
+			return err
+		}
+		points, err = runBenchUI(newBenchModel(opts, theme))
+	}
+	if werr := writeBenchReports(opts, points, *csvFlag, *jsonFlag); werr != nil {
+		return werr
+	}
+	if errors.Is(err, errBenchStopped) {
+		fmt.Fprintln(os.Stderr, err)
+		return nil
+	}
+	return err
+}
+
+// parseBenchSizes reads an increasing list such as "1e3,5e3,1e4".
+func parseBenchSizes(value string) ([]int64, error) {
+	var sizes []int64
+	for _, part := range strings.Split(value, ",") {
+		n, err := parseGenNumber(part)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid size %q", part)
+		}
+		sizes = append(sizes, n)
+	}
+	if !slices.IsSorted(sizes) {
+		return nil, fmt.Errorf("sizes must be increasing")
+	}
+	return sizes, nil
+}
+
+// newBenchSession compiles the solution and generator and resolves the GEN
+// variable that controls the input size.
+func newBenchSession(ctx context.Context, opts benchOptions, send func(tea.Msg)) (*benchSession, error) {
+	dir, err := os.MkdirTemp("", "defi-bench-")
+	if err != nil {
+		return nil, err
+	}
+	s := &benchSession{dir: dir, opts: opts}
+
+	if opts.generator == "" {
+		if s.spec, err = loadGenSpec(opts.solution); err == nil && s.spec == nil {
+			err = fmt.Errorf("no --gen given and no GEN directive in %s", opts.solution)
+		}
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		names := s.spec.Names()
+		if s.opts.variable == "" && len(names) > 0 {
+			s.opts.variable = names[0]
+		}
+		if !slices.Contains(names, s.opts.variable) {
+			s.Close()
+			return nil, fmt.Errorf("GEN has no variable %q to scale", s.opts.variable)
+		}
+	}
+
+	programs := []struct {
+		name string
+		path string
+		dest *string
+	}{
+		{"solution", opts.solution, &s.solution},
+		{"generator", opts.generator, &s.generator},
+	}
+	for i, p := range programs {
+		if p.path == "" {
+			continue
+		}
+		send(phaseMsg{Name: fmt.Sprintf("🛠️ Compiling %s", filepath.Base(p.path)), Index: i + 1, Total: len(programs)})
+		if *p.dest, err = prepareProgram(ctx, p.path, dir, p.name, opts.compileFlags); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Close removes the compiled programs.
+func (s *benchSession) Close() {
+	os.RemoveAll(s.dir)
+}
+
+// run measures every size in turn, stopping early when a run fails or
+// exceeds the time limit.
+func (s *benchSession) run(ctx context.Context, send func(tea.Msg)) error {
+	for _, size := range s.opts.sizes {
+		send(phaseMsg{Name: fmt.Sprintf("⏱️ Measuring n=%d", size)})
+		point, err := s.measure(ctx, size)
+		if ctx.Err() != nil {
+			return errRunCanceled
+		}
+		if errors.Is(err, errTimeLimitExceeded) {
+			return fmt.Errorf("%w: n=%d exceeded %s", errBenchStopped, size, s.opts.timeLimit)
+		}
+		if err != nil {
+			return fmt.Errorf("n=%d: %w", size, err)
+		}
+		send(benchPointMsg{Point: point})
+	}
+	return nil
+}
+
+// measure generates one input of the given size and times the solution on
+// it opts.reps times.
+func (s *benchSession) measure(ctx context.Context, size int64) (benchPoint, error) {
+	var (
+		inputs []string
+		err    error
+	)
+	if s.spec != nil {
+		inputs, err = s.spec.Generate(s.opts.seed, map[string]int64{s.opts.variable: size})
+	} else {
+		args := []string{strconv.FormatUint(s.opts.seed, 10), strconv.FormatInt(size, 10)}
+		inputs, err = execProgram(ctx, s.generator, args, nil, PromptLimits{})
+	}
+	if err != nil {
+		return benchPoint{}, fmt.Errorf("generator: %w", err)
+	}
+
+	point := benchPoint{Size: size}
+	for i := 0; i < s.opts.reps; i++ {
+		run, err := runProgram(ctx, s.solution, nil, inputs, PromptLimits{Time: s.opts.timeLimit})
+		if err != nil {
+			return point, err
+		}
+		point.Wall = append(point.Wall, run.Wall)
+		point.CPU = append(point.CPU, run.CPU)
+	}
+	return point, nil
+}
+
+// fitComplexity ranks the complexity models by how well they explain the
+// median timings, best first. It needs at least three sizes.
+func fitComplexity(points []benchPoint) []complexityFit {
+	if len(points) < 3 {
+		return nil
+	}
+
+	var fits []complexityFit
+	for _, model := range complexityModels {
+		var num, den float64
+		for _, p := range points {
+			f, t := model.f(float64(p.Size)), p.Time().Seconds()
+			num += t * f
+			den += f * f
+		}
+		if den == 0 {
+			continue
+		}
+		c := num / den
+
+		var sq float64
+		for _, p := range points {
+			t := p.Time().Seconds()
+			if t <= 0 {
+				continue
+			}
+			rel := (c*model.f(float64(p.Size)) - t) / t
+			sq += rel * rel
+		}
+		fits = append(fits, complexityFit{Name: model.name, Coefficient: c, Error: math.Sqrt(sq / float64(len(points)))})
+	}
+
+	sort.SliceStable(fits, func(i, j int) bool { return fits[i].Error < fits[j].Error })
+	return fits
+}
+
+// growthExponent is the slope of log(time) over log(n), e.g. about 2 for a
+// quadratic solution. It is zero with fewer than two usable points.
+func growthExponent(points []benchPoint) float64 {
+	var xs, ys []float64
+	for _, p := range points {
+		if t := p.Time().Seconds(); t > 0 {
+			xs = append(xs, math.Log(float64(p.Size)))
+			ys = append(ys, math.Log(t))
+		}
+	}
+	if len(xs) < 2 {
+		return 0
+	}
+
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(len(xs))
+	my /= float64(len(ys))
+
+	var num, den float64
+	for i := range xs {
+		num += (xs[i] - mx) * (ys[i] - my)
+		den += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// benchSummary describes the best fit in one line.
+func benchSummary(points []benchPoint) string {
+	fits := fitComplexity(points)
+	if len(fits) == 0 {
+		return "Need at least 3 sizes to fit a complexity"
+	}
+	return fmt.Sprintf("Best fit %s (error %.0f%%) • growth n^%.2f", fits[0].Name, fits[0].Error*100, growthExponent(points))
+}
+
+// runBenchPlain runs the benchmark without the TUI, printing one row per size.
+func runBenchPlain(opts benchOptions, out io.Writer) ([]benchPoint, error) {
+	ctx := context.Background()
+	s, err := newBenchSession(ctx, opts, func(tea.Msg) {})
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	var points []benchPoint
+	err = s.run(ctx, func(msg tea.Msg) {
+		if v, ok := msg.(benchPointMsg); ok {
+			points = append(points, v.Point)
+			fmt.Fprintf(out, "n=%-10d %10s  (wall %s)\n", v.Point.Size, formatBenchTime(v.Point.Time()), formatBenchTime(medianDuration(v.Point.Wall)))
+		}
+	})
+	fmt.Fprintln(out, benchSummary(points))
+	return points, err
+}
+
+// writeBenchReports writes the CSV and JSON reports that were asked for.
+func writeBenchReports(opts benchOptions, points []benchPoint, csvPath, jsonPath string) error {
+	if csvPath != "" {
+		if err := writeReportFile(csvPath, func(w io.Writer) error { return writeBenchCSV(w, points) }); err != nil {
+			return err
+		}
+	}
+	if jsonPath != "" {
+		if err := writeReportFile(jsonPath, func(w io.Writer) error { return writeBenchJSON(w, opts, points) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeReportFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %q: %w", path, err)
+	}
+	return f.Close()
+}
+
+// writeBenchCSV writes one row per repetition.
+func writeBenchCSV(w io.Writer, points []benchPoint) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"size", "rep", "wall_ms", "cpu_ms"})
+	for _, p := range points {
+		for i := range p.Wall {
+			cw.Write([]string{
+				strconv.FormatInt(p.Size, 10),
+				strconv.Itoa(i + 1),
+				strconv.FormatFloat(durationMillis(p.Wall[i]), 'f', 3, 64),
+				strconv.FormatFloat(durationMillis(p.CPU[i]), 'f', 3, 64),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// benchReport is the JSON layout of a benchmark.
+type benchReport struct {
+	Solution string             `json:"solution"`
+	Variable string             `json:"variable,omitempty"`
+	Reps     int                `json:"reps"`
+	Points   []benchReportPoint `json:"points"`
+	Fits     []benchReportFit   `json:"fits"`
+	Exponent float64            `json:"growth_exponent"`
+}
+
+type benchReportPoint struct {
+	Size         int64     `json:"size"`
+	WallMS       []float64 `json:"wall_ms"`
+	CPUMS        []float64 `json:"cpu_ms"`
+	MedianTimeMS float64   `json:"median_ms"`
+}
+
+type benchReportFit struct {
+	Model       string  `json:"model"`
+	Coefficient float64 `json:"coefficient"`
+	Error       float64 `json:"error"`
+}
+
+func writeBenchJSON(w io.Writer, opts benchOptions, points []benchPoint) error {
+	report := benchReport{
+		Solution: opts.solution,
+		Variable: opts.variable,
+		Reps:     opts.reps,
+		Points:   []benchReportPoint{},
+		Fits:     []benchReportFit{},
+		Exponent: growthExponent(points),
+	}
+	for _, p := range points {
+		rp := benchReportPoint{Size: p.Size, MedianTimeMS: durationMillis(p.Time())}
+		for i := range p.Wall {
+			rp.WallMS = append(rp.WallMS, durationMillis(p.Wall[i]))
+			rp.CPUMS = append(rp.CPUMS, durationMillis(p.CPU[i]))
+		}
+		report.Points = append(report.Points, rp)
+	}
+	for _, fit := range fitComplexity(points) {
+		report.Fits = append(report.Fits, benchReportFit{Model: fit.Name, Coefficient: fit.Coefficient, Error: fit.Error})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func medianDuration(values []time.Duration) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted[len(sorted)/2]
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// formatBenchTime renders a duration with three significant digits.
+func formatBenchTime(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.1fms", durationMillis(d))
+	}
+	return fmt.Sprintf("%dµs", d.Microseconds())
+}
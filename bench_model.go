@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pedrohff/defi/components"
+	"github.com/pedrohff/defi/view"
+)
+
+const (
+	benchHintRunning = "ctrl+c stop"
+	benchHintDone    = "q quit"
+)
+
+// benchModel drives `defi bench`: it measures the sizes in the background and
+// charts the timings as they arrive.
+type benchModel struct {
+	opts  benchOptions
+	theme components.Theme
+
+	spinner spinner.Model
+	updates <-chan tea.Msg
+	cancel  context.CancelFunc
+	start   tea.Cmd
+
+	running bool
+	points  []benchPoint
+	err     error
+	status  string
+
+	width  int
+	height int
+	ready  bool
+}
+
+func newBenchModel(opts benchOptions, theme components.Theme) benchModel {
+	ctx, cancel := context.WithCancel(context.Background())
+	theme = themeOrDefault(theme)
+	return benchModel{
+		opts:    opts,
+		theme:   theme,
+		spinner: components.NewSpinner(theme),
+		cancel:  cancel,
+		start:   startBenchCmd(ctx, opts),
+		running: true,
+		status:  statusPreparingRun,
+	}
+}
+
+func (m benchModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.start)
+}
+
+// startBenchCmd compiles the programs and measures every size, streaming
+// phases and points back to the model.
+func startBenchCmd(ctx context.Context, opts benchOptions) tea.Cmd {
+	return func() tea.Msg {
+		ch := make(chan tea.Msg, 16)
+		go func() {
+			defer close(ch)
+			send := func(msg tea.Msg) {
+				select {
+				case ch <- msg:
+				case <-ctx.Done():
+				}
+			}
+
+			session, err := newBenchSession(ctx, opts, send)
+			if err != nil {
+				send(benchDoneMsg{Err: err})
+				return
+			}
+			defer session.Close()
+			send(benchDoneMsg{Err: session.run(ctx, send)})
+		}()
+		return runnerStartedMsg{ctx: ctx, ch: ch}
+	}
+}
+
+func (m benchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.ready = true
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			m.cancel()
+			return m, tea.Quit
+		case "q":
+			if !m.running {
+				return m, tea.Quit
+			}
+		}
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case runnerStartedMsg:
+		if msg.ctx.Err() != nil {
+			return m, nil
+		}
+		m.updates = msg.ch
+		return m, readRunnerUpdateCmd(m.updates)
+
+	case runnerUpdateMsg:
+		if msg.ch != m.updates || msg.done {
+			return m, nil
+		}
+
+		switch v := msg.msg.(type) {
+		case phaseMsg:
+			m.status = v.Name
+		case benchPointMsg:
+			m.points = append(m.points, v.Point)
+		case benchDoneMsg:
+			m.running = false
+			m.err = v.Err
+			if v.Err != nil {
+				m.status = shortenString(v.Err.Error(), 60)
+			} else {
+				m.status = fmt.Sprintf("Measured %d sizes", len(m.points))
+			}
+		}
+		return m, readRunnerUpdateCmd(m.updates)
+	}
+
+	return m, nil
+}
+
+func (m benchModel) View() string {
+	if !m.ready {
+		return "🚀 Starting Défi...\n"
+	}
+
+	spin, hint := m.spinner.View(), benchHintRunning
+	if !m.running {
+		spin, hint = "✔", benchHintDone
+		if m.err != nil && !errors.Is(m.err, errBenchStopped) {
+			spin = "✘"
+		}
+	}
+
+	rows := benchChartRows(m.points)
+	summary := benchSummary(m.points)
+	return view.NewMainView(m.width, m.height, nil,
+		view.WithPanel(func(width, height int) string {
+			return components.BenchChart(m.theme, width, height-2, spin, rows, summary, hint)
+		}),
+		view.WithFilename(footerFilename(m.opts.solution)),
+		view.WithLanguage(languageLabelForPath(m.opts.solution)),
+		view.WithStatus(m.status),
+		view.WithTheme(m.theme),
+	).Render()
+}
+
+// benchChartRows pairs each measured size with the best fit's prediction.
+func benchChartRows(points []benchPoint) []components.BenchRow {
+	var best func(n float64) float64
+	if fits := fitComplexity(points); len(fits) > 0 {
+		for _, model := range complexityModels {
+			if model.name == fits[0].Name {
+				c, f := fits[0].Coefficient, model.f
+				best = func(n float64) float64 { return c * f(n) }
+			}
+		}
+	}
+
+	rows := make([]components.BenchRow, 0, len(points))
+	for _, p := range points {
+		row := components.BenchRow{
+			Label: fmt.Sprintf("n=%d", p.Size),
+			Value: p.Time().Seconds(),
+			Text:  formatBenchTime(p.Time()),
+		}
+		if best != nil {
+			row.Fit = best(float64(p.Size))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// runBenchUI runs the bench TUI and returns the points it measured.
+func runBenchUI(m benchModel) ([]benchPoint, error) {
+	finalModel, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return nil, err
+	}
+
+	fm, ok := finalModel.(benchModel)
+	if !ok {
+		return nil, errors.New("unexpected program state")
+	}
+
+	for _, p := range fm.points {
+		fmt.Printf("n=%-10d %s\n", p.Size, formatBenchTime(p.Time()))
+	}
+	fmt.Println(benchSummary(fm.points))
+	if errors.Is(fm.err, errRunCanceled) {
+		return fm.points, nil
+	}
+	return fm.points, fm.err
+}
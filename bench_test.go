@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func syntheticPoints(f func(n float64) float64) []benchPoint {
+	var points []benchPoint
+	for _, n := range defaultBenchSizes {
+		d := time.Duration(f(float64(n)))
+		points = append(points, benchPoint{Size: n, Wall: []time.Duration{d}, CPU: []time.Duration{d}})
+	}
+	return points
+}
+
+func TestFitComplexity(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        func(n float64) float64
+		want     string
+		exponent float64
+	}{
+		{"linear", func(n float64) float64 { return 50 * n }, "O(n)", 1},
+		{"n log n", func(n float64) float64 { return 20 * n * math.Log2(n) }, "O(n log n)", 1.1},
+		{"quadratic", func(n float64) float64 { return 0.5 * n * n }, "O(n²)", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := syntheticPoints(tt.f)
+			fits := fitComplexity(points)
+			if len(fits) == 0 || fits[0].Name != tt.want {
+				t.Fatalf("expected %s, got %+v", tt.want, fits)
+			}
+			if got := growthExponent(points); math.Abs(got-tt.exponent) > 0.1 {
+				t.Fatalf("expected growth exponent near %.1f, got %.2f", tt.exponent, got)
+			}
+		})
+	}
+
+	if fits := fitComplexity(syntheticPoints(func(n float64) float64 { return n })[:2]); fits != nil {
+		t.Fatalf("expected no fit with two points, got %+v", fits)
+	}
+}
+
+func TestParseBenchSizes(t *testing.T) {
+	sizes, err := parseBenchSizes("1e3, 5000,2e4")
+	if err != nil || len(sizes) != 3 || sizes[0] != 1000 || sizes[2] != 20000 {
+		t.Fatalf("unexpected sizes %v, %v", sizes, err)
+	}
+	if _, err := parseBenchSizes("1e4,1e3"); err == nil {
+		t.Fatal("expected decreasing sizes to be rejected")
+	}
+}
+
+func TestBenchCommandWritesReports(t *testing.T) {
+	dir := t.TempDir()
+	solution := filepath.Join(dir, "sol.sh")
+	if err := os.WriteFile(solution, []byte("#!/bin/sh\n: '/*defiprompt\nGEN: n = int(1, 10)\nGEN: array(n, int(1, 9))\n*/'\nread n; echo $n\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	csvPath := filepath.Join(dir, "bench.csv")
+	jsonPath := filepath.Join(dir, "bench.json")
+
+	var out bytes.Buffer
+	args := []string{solution, "--no-tui", "--sizes", "10,20,40", "--reps", "2", "--csv", csvPath, "--json", jsonPath}
+	if err := runBenchCommand(args, &out); err != nil {
+		t.Fatalf("runBenchCommand: %v", err)
+	}
+	if !strings.Contains(out.String(), "n=40") || !strings.Contains(out.String(), "Best fit") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	data, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 7 || lines[0] != "size,rep,wall_ms,cpu_ms" || !strings.HasPrefix(lines[6], "40,2,") {
+		t.Fatalf("unexpected CSV:\n%s", data)
+	}
+
+	var report benchReport
+	data, err = os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Points) != 3 || len(report.Points[1].WallMS) != 2 || len(report.Fits) != len(complexityModels) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestBenchStopsAtTimeLimit(t *testing.T) {
+	dir := t.TempDir()
+	opts := benchOptions{
+		solution:  writeScript(t, dir, "sol.sh", `read n; [ "$n" -gt 2 ] && exec sleep 5; echo $n`),
+		generator: writeScript(t, dir, "gen.sh", `echo "$2"`),
+		sizes:     []int64{1, 2, 3, 4},
+		reps:      1,
+		timeLimit: 200 * time.Millisecond,
+	}
+
+	var out bytes.Buffer
+	points, err := runBenchPlain(opts, &out)
+	if len(points) != 2 {
+		t.Fatalf("expected the two fast sizes, got %+v", points)
+	}
+	if err == nil || !strings.Contains(err.Error(), "n=3") {
+		t.Fatalf("expected the benchmark to stop at n=3, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pedrohff/defi/components"
+)
+
+// caseForm holds the editors used to author a new prompt case from the TUI.
+type caseForm struct {
+	inputs  textarea.Model
+	output  textarea.Model
+	focused int // 0 inputs, 1 expected output
+	theme   components.Theme
+}
+
+// caseAppendedMsg reports the outcome of appending a case to the source file.
+type caseAppendedMsg struct {
+	path string
+	err  error
+}
+
+func newCaseForm(theme components.Theme) *caseForm {
+	newField := func(placeholder string) textarea.Model {
+		ta := textarea.New()
+		ta.Placeholder = placeholder
+		ta.ShowLineNumbers = true
+		ta.CharLimit = 0
+		ta.MaxHeight = 0
+		return ta
+	}
+
+	f := &caseForm{
+		inputs: newField("one input line per row"),
+		output: newField("leave empty to record later"),
+		theme:  theme,
+	}
+	f.inputs.Focus()
+	return f
+}
+
+// Update routes key presses to the focused editor; tab moves between fields.
+func (f *caseForm) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyTab {
+		f.focused = 1 - f.focused
+		if f.focused == 0 {
+			f.output.Blur()
+			return f.inputs.Focus()
+		}
+		f.inputs.Blur()
+		return f.output.Focus()
+	}
+
+	var cmd tea.Cmd
+	if f.focused == 0 {
+		f.inputs, cmd = f.inputs.Update(msg)
+	} else {
+		f.output, cmd = f.output.Update(msg)
+	}
+	return cmd
+}
+
+// View renders the form within the given box.
+func (f *caseForm) View(width, height int) string {
+	fieldWidth := (width - 12) / 2
+	if fieldWidth < 10 {
+		fieldWidth = 10
+	}
+	fieldHeight := height - 8
+	if fieldHeight < 3 {
+		fieldHeight = 3
+	}
+	f.inputs.SetWidth(fieldWidth)
+	f.inputs.SetHeight(fieldHeight)
+	f.output.SetWidth(fieldWidth)
+	f.output.SetHeight(fieldHeight)
+
+	return components.CaseForm(f.theme, width, height-2, f.inputs.View(), f.output.View(), f.focused)
+}
+
+// Lines returns the non-empty lines entered in both editors.
+func (f *caseForm) Lines() (inputs, outputs []string) {
+	return formLines(f.inputs.Value()), formLines(f.output.Value())
+}
+
+func formLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// appendCaseCmd writes a new prompt case to the end of the last defiprompt block.
+func appendCaseCmd(path string, inputs, outputs []string) tea.Cmd {
+	return func() tea.Msg {
+		err := updateSourceFile(path, func(content string) (string, error) {
+			return appendPromptCase(content, inputs, outputs)
+		})
+		return caseAppendedMsg{path: path, err: err}
+	}
+}
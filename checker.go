@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// outputChecker decides whether the output of a solution answers a case.
+type outputChecker interface {
+	Check(inputs, expected, actual []string) error
+}
+
+// exactChecker compares line by line, ignoring surrounding whitespace.
+type exactChecker struct{}
+
+func (exactChecker) Check(_, expected, actual []string) error {
+	return compareOutputs(expected, actual)
+}
+
+// tokenChecker compares whitespace separated tokens, ignoring line breaks.
+type tokenChecker struct{}
+
+func (tokenChecker) Check(_, expected, actual []string) error {
+	return compareTokens(expected, actual, func(want, got string) bool { return want == got })
+}
+
+// floatChecker compares tokens, accepting numbers within an absolute or
+// relative error of epsilon.
+type floatChecker struct {
+	epsilon float64
+}
+
+func (c floatChecker) Check(_, expected, actual []string) error {
+	return compareTokens(expected, actual, func(want, got string) bool {
+		w, errW := strconv.ParseFloat(want, 64)
+		g, errG := strconv.ParseFloat(got, 64)
+		if errW != nil || errG != nil {
+			return want == got
+		}
+		diff := math.Abs(w - g)
+		return diff <= c.epsilon || diff <= c.epsilon*math.Abs(w)
+	})
+}
+
+func compareTokens(expected, actual []string, equal func(want, got string) bool) error {
+	want := strings.Fields(strings.Join(expected, "\n"))
+	got := strings.Fields(strings.Join(actual, "\n"))
+	for i := 0; i < len(want) && i < len(got); i++ {
+		if !equal(want[i], got[i]) {
+			return fmt.Errorf("expected token %q, got %q (token %d)", want[i], got[i], i+1)
+		}
+	}
+	if len(want) != len(got) {
+		return fmt.Errorf("expected %d output tokens, got %d", len(want), len(got))
+	}
+	return nil
+}
+
+// programChecker runs a testlib-style checker as
+// "checker <input> <output> <answer>"; a zero exit status accepts the output.
+type programChecker struct {
+	path string
+}
+
+func (c programChecker) Check(inputs, expected, actual []string) error {
+	dir, err := os.MkdirTemp("", "defi-check-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	files := []struct {
+		name  string
+		lines []string
+	}{{"input", inputs}, {"output", actual}, {"answer", expected}}
+	args := make([]string, len(files))
+	for i, f := range files {
+		args[i] = filepath.Join(dir, f.name)
+		if err := writeCaseFile(args[i], f.lines); err != nil {
+			return err
+		}
+	}
+
+	out, err := exec.Command(c.path, args...).CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("checker: %s", strings.SplitN(msg, "\n", 2)[0])
+		}
+		return fmt.Errorf("checker rejected the output (exit status %d)", exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("checker failed: %w", err)
+	}
+	return nil
+}
+
+// newChecker builds the checker named by spec: "exact" (the default),
+// "tokens", "float" or "float:EPS", or the path to a checker program, which is
+// compiled into dir when it is a supported source.
+func newChecker(ctx context.Context, spec, dir string) (outputChecker, error) {
+	switch {
+	case spec == "" || spec == "exact":
+		return exactChecker{}, nil
+	case spec == "tokens":
+		return tokenChecker{}, nil
+	case spec == "float":
+		return floatChecker{epsilon: 1e-6}, nil
+	case strings.HasPrefix(spec, "float:"):
+		eps, err := strconv.ParseFloat(strings.TrimPrefix(spec, "float:"), 64)
+		if err != nil || eps < 0 {
+			return nil, fmt.Errorf("invalid float checker tolerance in %q", spec)
+		}
+		return floatChecker{epsilon: eps}, nil
+	}
+
+	path, err := prepareProgram(ctx, spec, dir, "checker", nil)
+	if err != nil {
+		return nil, fmt.Errorf("checker: %w", err)
+	}
+	return programChecker{path: path}, nil
+}
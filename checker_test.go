@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBuiltinCheckers(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected []string
+		actual   []string
+		ok       bool
+	}{
+		{"exact", []string{"1 2"}, []string{"1 2 "}, true},
+		{"exact", []string{"1 2"}, []string{"1", "2"}, false},
+		{"tokens", []string{"1 2"}, []string{"1", "2"}, true},
+		{"tokens", []string{"1 2"}, []string{"1 3"}, false},
+		{"float", []string{"0.333333"}, []string{"0.3333332"}, true},
+		{"float:1e-2", []string{"10.0 abc"}, []string{"10.05 abc"}, true},
+		{"float:1e-9", []string{"10.0"}, []string{"10.05"}, false},
+	}
+
+	for _, tt := range tests {
+		checker, err := newChecker(context.Background(), tt.spec, t.TempDir())
+		if err != nil {
+			t.Fatalf("newChecker(%q): %v", tt.spec, err)
+		}
+		err = checker.Check(nil, tt.expected, tt.actual)
+		if (err == nil) != tt.ok {
+			t.Fatalf("%s checker on %q vs %q: got %v, want ok=%v", tt.spec, tt.expected, tt.actual, err, tt.ok)
+		}
+	}
+}
+
+func TestProgramChecker(t *testing.T) {
+	dir := t.TempDir()
+	path := writeScript(t, dir, "check.sh", `cmp -s "$2" "$3" || { echo "wrong answer"; exit 1; }`)
+
+	checker, err := newChecker(context.Background(), path, dir)
+	if err != nil {
+		t.Fatalf("newChecker: %v", err)
+	}
+	if err := checker.Check([]string{"1"}, []string{"2"}, []string{"2"}); err != nil {
+		t.Fatalf("expected accepted output, got %v", err)
+	}
+	if err := checker.Check([]string{"1"}, []string{"2"}, []string{"3"}); err == nil || err.Error() != "checker: wrong answer" {
+		t.Fatalf("expected the checker message, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// BenchRow is one input size of a benchmark chart.
+type BenchRow struct {
+	Label string
+	// Value is the measured time and Fit the time predicted by the best
+	// complexity fit, in the same unit.
+	Value float64
+	Fit   float64
+	// Text is shown after the bar, typically the formatted time.
+	Text string
+}
+
+// BenchChart renders benchmark timings as horizontal bars, one per input
+// size, with a ┃ marking where the fitted curve puts each size.
+func BenchChart(theme Theme, width int, height int, spinner string, rows []BenchRow, summary string, hint string) string {
+	benchLabel := lipgloss.NewStyle().Foreground(theme.Muted)
+	benchValue := lipgloss.NewStyle().Foreground(theme.Text)
+	lines := []string{nameTag(theme, " Benchmark"), ""}
+
+	labelWidth, textWidth := 0, 0
+	scale := 0.0
+	for _, r := range rows {
+		labelWidth = max(labelWidth, lipgloss.Width(r.Label))
+		textWidth = max(textWidth, lipgloss.Width(r.Text))
+		scale = max(scale, r.Value, r.Fit)
+	}
+	// Leave room for the padding and border of the container.
+	barWidth := max(width-labelWidth-textWidth-10, 10)
+
+	for _, r := range rows {
+		bar := benchBarCells(theme, r.Value, r.Fit, scale, barWidth)
+		lines = append(lines, fmt.Sprintf("%s %s %s",
+			benchLabel.Render(fmt.Sprintf("%*s", labelWidth, r.Label)),
+			bar,
+			benchValue.Render(r.Text),
+		))
+	}
+	if len(rows) == 0 {
+		lines = append(lines, benchLabel.Render("waiting for the first size..."))
+	}
+
+	lines = append(lines,
+		"",
+		stressCounter(theme).UnsetMarginTop().Render(fmt.Sprintf("%s %s", spinner, summary)),
+		stressHint(theme).Render(hint),
+	)
+
+	return detailsContainer(theme).Width(width).Height(height).Render(
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
+	)
+}
+
+// benchBarCells draws a bar of value relative to scale, overlaying the fit
+// marker when there is one.
+func benchBarCells(theme Theme, value, fit, scale float64, width int) string {
+	benchBar := lipgloss.NewStyle().Foreground(theme.Accent)
+	benchFit := lipgloss.NewStyle().Foreground(theme.Warning).Bold(true)
+	if scale <= 0 {
+		return strings.Repeat(" ", width)
+	}
+	filled := int(math.Round(value / scale * float64(width)))
+	marker := -1
+	if fit > 0 {
+		marker = min(int(math.Round(fit/scale*float64(width))), width-1)
+	}
+
+	if marker < 0 {
+		return benchBar.Render(strings.Repeat("█", filled)) + strings.Repeat(" ", width-filled)
+	}
+	cells := func(from, to int) string {
+		if to <= from {
+			return ""
+		}
+		n := max(min(to, filled)-from, 0)
+		return benchBar.Render(strings.Repeat("█", n)) + strings.Repeat(" ", to-from-n)
+	}
+	return cells(0, marker) + benchFit.Render("┃") + cells(marker+1, width)
+}
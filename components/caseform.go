@@ -0,0 +1,43 @@
+package components
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// CaseForm renders the new test case editor with its inputs and expected
+// output fields. focused is 0 for inputs and 1 for the expected output.
+func CaseForm(theme Theme, width int, height int, inputsField string, outputField string, focused int) string {
+	// The editor receiving input is outlined with the accent.
+	field := lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, false, false, true)
+	caseFormFieldFocused := field.BorderForeground(theme.Accent)
+	caseFormFieldBlurred := field.BorderForeground(theme.Muted)
+
+	inputsStyle, outputStyle := caseFormFieldFocused, caseFormFieldBlurred
+	if focused == 1 {
+		inputsStyle, outputStyle = caseFormFieldBlurred, caseFormFieldFocused
+	}
+
+	inputsSection := lipgloss.JoinVertical(
+		lipgloss.Left,
+		detailsSectionTitle(theme).Render("󱋴 INPUTS"),
+		inputsStyle.Render(inputsField),
+	)
+	outputSection := lipgloss.JoinVertical(
+		lipgloss.Left,
+		detailsSectionTitle(theme).Render("󱋲 EXPECTED (optional)"),
+		outputStyle.Render(outputField),
+	)
+
+	hint := stressHint(theme).Render("tab switch field • ctrl+s save • esc cancel")
+
+	return detailsContainer(theme).Width(width).Height(height).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Center,
+			nameTag(theme, " New case"),
+			lipgloss.NewStyle().MarginTop(1).Render(
+				lipgloss.JoinHorizontal(lipgloss.Top, inputsSection, "  ", outputSection),
+			),
+			hint,
+		),
+	)
+}
@@ -0,0 +1,70 @@
+package components
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// FileListWidth is the width reserved for the watched files sidebar.
+const FileListWidth = 26
+
+// FileListEntry describes one watched file and its last run summary.
+type FileListEntry struct {
+	Name   string
+	Status string // TestCasePending, TestCaseRunning, TestCaseBlockStatusPass or TestCaseBlockStatusFail
+	Passed int
+	Total  int
+	Active bool
+}
+
+// FileList renders the sidebar listing watched files with their pass/fail summary.
+func FileList(theme Theme, width int, height int, entries []FileListEntry) string {
+	fileListTitle := lipgloss.NewStyle().
+		Foreground(theme.HeaderFG).
+		Background(theme.HeaderBG).
+		Bold(true).Italic(true).
+		Padding(0, 1)
+	fileListEntry := lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Padding(0, 1)
+	// fileListBorder separates the sidebar from the test list.
+	fileListBorder := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, true, false, false).
+		BorderForeground(theme.Muted)
+
+	inner := width - 1 // border
+	rows := []string{fileListTitle.Width(inner).Render("FILES")}
+
+	for _, entry := range entries {
+		marker, summary := "·", ""
+		color := theme.Subtle
+		switch entry.Status {
+		case TestCaseRunning:
+			marker, color = "…", theme.Warning
+		case TestCaseBlockStatusPass:
+			marker, color = "✔", theme.Success
+		case TestCaseBlockStatusFail:
+			marker, color = "✘", theme.Failure
+		}
+		if entry.Status != TestCasePending {
+			summary = fmt.Sprintf(" %d/%d", entry.Passed, entry.Total)
+		}
+
+		name := entry.Name
+		if max := inner - 4 - len(summary); len([]rune(name)) > max && max > 1 {
+			name = string([]rune(name)[:max-1]) + "…"
+		}
+
+		style := fileListEntry.Width(inner)
+		if entry.Active {
+			style = style.Background(theme.Selection).Foreground(theme.SelectionText)
+		}
+		rows = append(rows, style.Render(
+			lipgloss.NewStyle().Foreground(color).Render(marker)+" "+name+summary,
+		))
+	}
+
+	list := lipgloss.JoinVertical(lipgloss.Left, rows...)
+	return fileListBorder.Height(height).Render(lipgloss.PlaceVertical(height, lipgloss.Top, list))
+}
@@ -6,39 +6,63 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	// FooterStatusTabStyle styles the static "STATUS" label in the footer.
-	FooterStatusTabStyle = lipgloss.NewStyle().
-				Foreground(ColorWhite).
-				Background(ColorAlert).
-				Align(lipgloss.Center).Padding(0, 1)
-
-	// FooterStatusDescStyle styles the dynamic status description area.
-	FooterStatusDescStyle = lipgloss.NewStyle().
-				Foreground(ColorMutedAccent).
-				Background(ColorSurfaceDark).Padding(0, 1)
+// footerStyles styles the footer sections: the static "STATUS" label, the
+// dynamic status description and the language and filename badges.
+func footerStyles(theme Theme) (statusTab, statusDesc, language, filename lipgloss.Style) {
+	statusTab = lipgloss.NewStyle().
+		Foreground(theme.OnAccent).
+		Background(theme.Alert).
+		Align(lipgloss.Center).Padding(0, 1)
+	statusDesc = lipgloss.NewStyle().
+		Foreground(theme.StatusText).
+		Background(theme.Surface).Padding(0, 1)
+	language = lipgloss.NewStyle().
+		Foreground(theme.OnAccent).
+		Background(theme.LanguageBadge).
+		Align(lipgloss.Center)
+	filename = lipgloss.NewStyle().
+		Foreground(theme.OnAccent).
+		Background(theme.FilenameBadge).
+		Align(lipgloss.Center).Padding(0, 1)
+	return statusTab, statusDesc, language, filename
+}
 
-	FooterLanguageStyle = lipgloss.NewStyle().
-				Foreground(ColorWhite).
-				Background(ColorLanguageBadge).
-				Align(lipgloss.Center)
+// Widths of the fixed footer sections; the status takes the rest.
+const (
+	footerStatusTabWidth = 8
+	footerLanguageWidth  = 10
+	footerFilenameWidth  = 20
+)
 
-	FooterFilenameStyle = lipgloss.NewStyle().
-				Foreground(ColorWhite).
-				Background(ColorFilenameBadge).
-				Align(lipgloss.Center).Padding(0, 1)
+// Footer tags reported by FooterTagAt.
+const (
+	FooterTagLanguage = "language"
+	FooterTagFilename = "filename"
 )
 
 // Footer composes the footer layout with the current status message.
-func Footer(width int, status string, language string, filename string) string {
+func Footer(theme Theme, width int, status string, language string, filename string) string {
+	statusTab, statusDesc, languageStyle, filenameStyle := footerStyles(theme)
 	if len(filename) > 18 {
 		filename = strings.TrimSpace(filename[:17]) + "…"
 	}
 	return lipgloss.JoinHorizontal(
 		lipgloss.Left,
-		FooterStatusTabStyle.Render("STATUS"),
-		FooterStatusDescStyle.Width(width-8-10-20).Render(status),
-		FooterLanguageStyle.Width(10).Render(language),
-		FooterFilenameStyle.Width(20).Render(filename),
+		statusTab.Render("STATUS"),
+		statusDesc.Width(width-footerStatusTabWidth-footerLanguageWidth-footerFilenameWidth).Render(status),
+		languageStyle.Width(footerLanguageWidth).Render(language),
+		filenameStyle.Width(footerFilenameWidth).Render(filename),
 	)
 }
+
+// FooterTagAt returns the footer tag at column x of a footer of the given
+// width, or "" when x is over the status.
+func FooterTagAt(width, x int) string {
+	switch {
+	case x >= width || x < width-footerFilenameWidth-footerLanguageWidth:
+		return ""
+	case x >= width-footerFilenameWidth:
+		return FooterTagFilename
+	}
+	return FooterTagLanguage
+}
@@ -3,15 +3,17 @@ package components
 
 import "github.com/charmbracelet/lipgloss"
 
-// HeaderStyle defines the default presentation for header titles.
-var HeaderStyle = lipgloss.NewStyle().
-	Foreground(ColorWhite).
-	Background(ColorSuccess).
-	Align(lipgloss.Center).Bold(true).Italic(true).MarginBottom(1)
+// headerStyle defines the default presentation for header titles.
+func headerStyle(theme Theme) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(theme.OnAccent).
+		Background(theme.Success).
+		Align(lipgloss.Center).Bold(true).Italic(true).MarginBottom(1)
+}
 
 // Header renders content centered within a styled header of the given width.
-func Header(width int, content string) string {
-	return HeaderStyle.
+func Header(theme Theme, width int, content string) string {
+	return headerStyle(theme).
 		Width(width).
 		Render(content)
 }
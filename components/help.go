@@ -0,0 +1,43 @@
+package components
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// helpBar pads the help bar like the footer.
+var helpBar = lipgloss.NewStyle().Padding(0, 1)
+
+// HelpStyles styles bubbles/help with the theme.
+func HelpStyles(theme Theme) help.Styles {
+	helpKey := lipgloss.NewStyle().Foreground(theme.Text)
+	helpDesc := lipgloss.NewStyle().Foreground(theme.Muted)
+	helpSeparator := lipgloss.NewStyle().Foreground(theme.Subtle)
+	return help.Styles{
+		Ellipsis:       helpSeparator,
+		ShortKey:       helpKey,
+		ShortDesc:      helpDesc,
+		ShortSeparator: helpSeparator,
+		FullKey:        helpKey,
+		FullDesc:       helpDesc,
+		FullSeparator:  helpSeparator,
+	}
+}
+
+// HelpBar renders the one-line key help shown above the footer.
+func HelpBar(width int, keys string) string {
+	return helpBar.Width(width).MaxHeight(1).Render(keys)
+}
+
+// HelpOverlay frames the full key help, shown on top of the body.
+func HelpOverlay(theme Theme, keys string, hint string) string {
+	return detailsContainer(theme).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Center,
+			nameTag(theme, " Keys"),
+			"",
+			keys,
+			stressHint(theme).Render(hint),
+		),
+	)
+}
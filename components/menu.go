@@ -0,0 +1,58 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// menuItemsTop is the row of the first item: border, padding, title and the
+// blank line under it.
+const menuItemsTop = 4
+
+// Menu renders a framed list of items with the selected one highlighted. A
+// marked item, such as the current choice, is prefixed with a dot.
+func Menu(theme Theme, title string, items []string, selected, marked int, hint string) string {
+	menuItem := lipgloss.NewStyle().Foreground(theme.Text).Padding(0, 1)
+	// menuItemSelected highlights the entry the menu's cursor is on.
+	menuItemSelected := menuItem.
+		Foreground(theme.Inverse).
+		Background(theme.Accent)
+
+	width := lipgloss.Width(hint)
+	for _, item := range items {
+		width = max(width, lipgloss.Width(item)+4)
+	}
+
+	rows := make([]string, len(items))
+	for i, item := range items {
+		prefix := "  "
+		if i == marked {
+			prefix = "• "
+		}
+		style := menuItem
+		if i == selected {
+			style = menuItemSelected
+		}
+		rows[i] = style.Width(width).Render(prefix + item)
+	}
+
+	return detailsContainer(theme).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			nameTag(theme, " "+title),
+			"",
+			strings.Join(rows, "\n"),
+			stressHint(theme).Render(hint),
+		),
+	)
+}
+
+// MenuItemAt returns the index of the item at row y of a menu of n items, or
+// -1 when y is not on an item.
+func MenuItemAt(n, y int) int {
+	if y < menuItemsTop || y >= menuItemsTop+n {
+		return -1
+	}
+	return y - menuItemsTop
+}
@@ -0,0 +1,66 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SourceDiff renders unified diff lines ("+", "-", " " and "@@" prefixed)
+// starting at offset, with a title and a hint underneath.
+func SourceDiff(theme Theme, width int, height int, title string, lines []string, offset int, hint string) string {
+	diffAdded := lipgloss.NewStyle().Foreground(theme.Success)
+	diffRemoved := lipgloss.NewStyle().Foreground(theme.Failure)
+	diffHunk := lipgloss.NewStyle().Foreground(theme.Accent)
+	diffContext := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	// Leave room for the tag, the hint and the container's padding.
+	visible := max(height-6, 1)
+	offset = max(min(offset, len(lines)-visible), 0)
+	end := min(offset+visible, len(lines))
+	textWidth := max(width-6, 1)
+
+	rendered := make([]string, 0, end-offset)
+	for _, line := range lines[offset:end] {
+		line = truncateLine(line, textWidth)
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			rendered = append(rendered, diffHunk.Render(line))
+		case strings.HasPrefix(line, "+"):
+			rendered = append(rendered, diffAdded.Render(line))
+		case strings.HasPrefix(line, "-"):
+			rendered = append(rendered, diffRemoved.Render(line))
+		default:
+			rendered = append(rendered, diffContext.Render(line))
+		}
+	}
+
+	return detailsContainer(theme).Width(width).Height(height).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			nameTag(theme, " "+truncateLine(title, max(width-10, 1))),
+			"",
+			lipgloss.NewStyle().Height(visible).Render(strings.Join(rendered, "\n")),
+			stressHint(theme).Render(hint),
+		),
+	)
+}
+
+// truncateLine cuts line to width cells, marking the cut with an ellipsis.
+func truncateLine(line string, width int) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	if lipgloss.Width(line) <= width {
+		return line
+	}
+	var b strings.Builder
+	used := 0
+	for _, r := range line {
+		w := lipgloss.Width(string(r))
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	return b.String() + "…"
+}
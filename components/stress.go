@@ -0,0 +1,40 @@
+package components
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// stressCounter styles the number of tests run so far.
+func stressCounter(theme Theme) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		MarginTop(1)
+}
+
+// stressHint styles the key hints under the stress progress.
+func stressHint(theme Theme) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true).
+		MarginTop(1)
+}
+
+// StressProgress renders the stress testing panel with the number of tests
+// that matched the reference so far and the seed being tried.
+func StressProgress(theme Theme, width int, height int, spinner string, tested int, seed int, hint string) string {
+	counter := stressCounter(theme).Render(fmt.Sprintf("%s %d tests passed", spinner, tested))
+	seedLine := lipgloss.NewStyle().Foreground(theme.Muted).Render(fmt.Sprintf("seed %d", seed))
+
+	return detailsContainer(theme).Width(width).Height(height).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Center,
+			nameTag(theme, " Stress testing"),
+			counter,
+			seedLine,
+			stressHint(theme).Render(hint),
+		),
+	)
+}
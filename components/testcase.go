@@ -1,6 +1,10 @@
 package components
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 const (
 	// TestCasePending indicates that a case has not yet started.
@@ -17,95 +21,122 @@ const (
 	TestCaseBlockStatusFail = "FAIL"
 	// TestCaseBlockSize defines the width reserved for each result block.
 	TestCaseBlockSize = 9
+	// TestCaseRegressionFailing marks a case that passed in the previous run.
+	TestCaseRegressionFailing = "REGRESSED"
+	// TestCaseRegressionSlower marks a case that got much slower since the
+	// previous run.
+	TestCaseRegressionSlower = "SLOWER"
 )
 
-var (
-	// TestCaseNameStyle styles the test case label when a result is available.
-	TestCaseNameStyle = lipgloss.NewStyle().Padding(0, 1)
-
-	// TestCaseNameStylePending styles the test case label while pending.
-	TestCaseNameStylePending = TestCaseNameStyle.Foreground(ColorPendingLabel)
-
-	// TestCaseResultBlockPendingStyle styles a block representing a pending result.
-	TestCaseResultBlockPendingStyle = lipgloss.NewStyle().
-					Width(TestCaseBlockSize).Align(lipgloss.Center).
-					Italic(true).
-					Foreground(ColorPendingBlockFG).
-					Background(ColorPendingBlockBG).Padding(0, 1)
-
-	// TestCaseResultBlockRunningStyle styles a block while the case is in flight.
-	TestCaseResultBlockRunningStyle = lipgloss.NewStyle().
-					Width(TestCaseBlockSize).Align(lipgloss.Center).
-					Foreground(ColorSuccess).
-					Background(ColorPendingBlockBG).Padding(0, 1)
-
-	// TestCaseResultBlockPassedStyle styles a block when the case succeeds.
-	TestCaseResultBlockPassedStyle = lipgloss.NewStyle().
-					Width(TestCaseBlockSize).Align(lipgloss.Center).
-					Bold(true).
-					Foreground(ColorWhite).
-					Background(ColorSuccess).Padding(0, 1)
+// testCaseStyles holds the styles of a test case row.
+type testCaseStyles struct {
+	// name styles the test case label when a result is available and
+	// namePending while it is pending.
+	name        lipgloss.Style
+	namePending lipgloss.Style
+	// pending, running, passed and failed style the result blocks.
+	pending lipgloss.Style
+	running lipgloss.Style
+	passed  lipgloss.Style
+	failed  lipgloss.Style
+	// regression styles the regression marker after the name.
+	regression lipgloss.Style
+}
 
-	// TestCaseResultBlockFailedStyle styles a block when the case fails.
-	TestCaseResultBlockFailedStyle = lipgloss.NewStyle().
-					Width(TestCaseBlockSize).Align(lipgloss.Center).
-					Bold(true).
-					Foreground(ColorSurfaceDark).
-					Background(ColorAlert).Padding(0, 1)
-)
+func newTestCaseStyles(theme Theme) testCaseStyles {
+	name := lipgloss.NewStyle().Padding(0, 1)
+	block := lipgloss.NewStyle().Width(TestCaseBlockSize).Align(lipgloss.Center).Padding(0, 1)
+	return testCaseStyles{
+		name:        name,
+		namePending: name.Foreground(theme.Subtle),
+		pending: block.
+			Italic(true).
+			Foreground(theme.PendingFG).
+			Background(theme.PendingBG),
+		running: block.
+			Foreground(theme.Success).
+			Background(theme.PendingBG),
+		passed: block.
+			Bold(true).
+			Foreground(theme.OnAccent).
+			Background(theme.Success),
+		failed: block.
+			Bold(true).
+			Foreground(theme.Inverse).
+			Background(theme.Alert),
+		regression: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(theme.Warning),
+	}
+}
 
-// TestCaseHeader renders the column headers used by TestCase rows.
-func TestCaseHeader(width int) string {
+// TestCaseHeader renders the column headers used by TestCase rows. A
+// non-empty position, such as "13-24 of 60", is shown for scrolled lists.
+func TestCaseHeader(theme Theme, width int, position string) string {
 	headerStyle := lipgloss.NewStyle().
-		Foreground(ColorHeaderMutedFG).
-		Background(ColorHeaderMutedBG).
+		Foreground(theme.HeaderFG).
+		Background(theme.HeaderBG).
 		Width(width).
 		Align(lipgloss.Left).Bold(true).Italic(true)
 
+	nameWidth := width - (2 * TestCaseBlockSize)
+	label := "TEST CASE"
+	if position != "" {
+		gap := max(nameWidth-lipgloss.Width(label)-lipgloss.Width(position)-1, 1)
+		label += strings.Repeat(" ", gap) + position
+	}
+
 	return lipgloss.JoinHorizontal(
 		lipgloss.Left,
-		headerStyle.Width(width-(2*TestCaseBlockSize)).Render("TEST CASE"),
+		headerStyle.Width(nameWidth).Render(label),
 		headerStyle.Width(TestCaseBlockSize).Render("COMPILE"),
 		headerStyle.Width(TestCaseBlockSize).Render("ASSERT"),
 	)
 }
 
-// TestCase renders a single test case row with compilation and assertion result blocks.
-func TestCase(width int, name string, status string, compileSuccess bool, assertionSuccess bool, isSelected bool) string {
-	testCaseNameStyle := TestCaseNameStylePending
-	compileStyle := TestCaseResultBlockPendingStyle
-	assertionSuccessStyle := TestCaseResultBlockPendingStyle
+// TestCase renders a single test case row with compilation and assertion
+// result blocks. A non-empty regression is shown next to the name.
+func TestCase(theme Theme, width int, name string, status string, compileSuccess bool, assertionSuccess bool, regression string, isSelected bool) string {
+	styles := newTestCaseStyles(theme)
+	testCaseNameStyle := styles.namePending
+	compileStyle := styles.pending
+	assertionSuccessStyle := styles.pending
 	compileStatus := TestCaseBlockStatusPending
 	assertionStatus := TestCaseBlockStatusPending
 
 	switch status {
 	case TestCaseFinished:
-		testCaseNameStyle = TestCaseNameStyle
+		testCaseNameStyle = styles.name
 		if compileSuccess {
-			compileStyle = TestCaseResultBlockPassedStyle
+			compileStyle = styles.passed
 			compileStatus = TestCaseBlockStatusPass
 		} else {
-			compileStyle = TestCaseResultBlockFailedStyle
+			compileStyle = styles.failed
 			compileStatus = TestCaseBlockStatusFail
 		}
 
 		if assertionSuccess {
-			assertionSuccessStyle = TestCaseResultBlockPassedStyle
+			assertionSuccessStyle = styles.passed
 			assertionStatus = TestCaseBlockStatusPass
 		} else {
-			assertionSuccessStyle = TestCaseResultBlockFailedStyle
+			assertionSuccessStyle = styles.failed
 			assertionStatus = TestCaseBlockStatusFail
 		}
 	case TestCaseRunning:
-		testCaseNameStyle = TestCaseNameStyle
-		compileStyle = TestCaseResultBlockRunningStyle
-		assertionSuccessStyle = TestCaseResultBlockRunningStyle
+		testCaseNameStyle = styles.name
+		compileStyle = styles.running
+		assertionSuccessStyle = styles.running
 	}
 
 	testCaseNameColumn := testCaseNameStyle.Width(width - (2 * TestCaseBlockSize))
 
+	regressionStyle := styles.regression
 	if isSelected {
-		testCaseNameColumn = testCaseNameColumn.Background(ColorSelectedBg)
+		testCaseNameColumn = testCaseNameColumn.Background(theme.Selection).Foreground(theme.SelectionText)
+		regressionStyle = regressionStyle.Background(theme.Selection)
+	}
+	if regression != "" {
+		name += " " + regressionStyle.Render("▲ "+regression)
 	}
 
 	return lipgloss.JoinHorizontal(
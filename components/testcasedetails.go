@@ -1,66 +1,119 @@
 package components
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	// detailsSectionTitle styles the label above each detail section.
-	detailsSectionTitle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(ColorAccentBlue).
-				MarginBottom(1)
-
-	// detailsContent styles the content block of each section.
-	detailsContent = lipgloss.NewStyle().
-			Foreground(ColorTextPrimary).
-			Background(ColorSurfaceDark).
-			Padding(0, 2)
-
-	// detailsContainer wraps the entire details pane.
-	detailsContainer = lipgloss.NewStyle().
-				Padding(1, 2).
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(ColorTextMuted)
-)
+// detailsSectionTitle styles the label above each detail section.
+func detailsSectionTitle(theme Theme) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Bold(true).
+		Foreground(theme.Accent).
+		MarginBottom(1)
+}
 
-// TestCaseDetails renders a details pane showing test inputs, expected output,
-// and actual execution output side by side (or stacked if width is limited).
-// inspiration https://www.gh-dash.dev
-func TestCaseDetails(width int, height int, name string, testInputs []string, expectedOutput string, executionOutput string) string {
+// detailsContent styles the content block of each section.
+func detailsContent(theme Theme) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Background(theme.Surface).
+		Padding(0, 2)
+}
+
+// detailsContainer wraps the entire details pane.
+func detailsContainer(theme Theme) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Muted)
+}
 
-	detailsContainer = detailsContainer.Height(height)
-	// Section widths
-	sectionWidth := (width - 8) / 3
-	if sectionWidth < 20 {
-		sectionWidth = 20
+// DetailsScroll is the scroll state of a details pane. Expected and actual
+// outputs scroll together so matching lines stay side by side. Focused is 0
+// when the inputs receive scroll keys and 1 for the outputs.
+type DetailsScroll struct {
+	Inputs  int
+	Outputs int
+	Focused int
+}
+
+// detailsChromeRows is the height of everything in the details pane but the
+// viewports: padding, name tag, margin, two labels with their margins and
+// the spacer between the rows.
+const detailsChromeRows = 9
+
+// DetailsViewportRows returns how many lines the inputs/expected row and the
+// output row show in a details pane of the given height. Each row shrinks to
+// its content, leaving the rest of the space to the other.
+func DetailsViewportRows(height int, inputs, expected, actual int) (top, bottom int) {
+	available := max(height-detailsChromeRows, 2)
+	top = min(max(inputs, expected, 1), max(available/2, available-max(actual, 1)))
+	bottom = max(min(max(actual, 1), available-top), 1)
+	return max(top, 1), bottom
+}
+
+// DetailsSectionAt returns which viewport of a details pane rendered with
+// TestCaseDetails(width, height, ...) is at column x and row y of the pane:
+// 0 for the inputs, 1 for the outputs (expected or actual) and -1 for the
+// pane's chrome.
+func DetailsSectionAt(width, height int, inputs, expected, actual int, x, y int) int {
+	top, _ := DetailsViewportRows(height, inputs, expected, actual)
+	// Border, padding, name tag and its margin come before the labels.
+	const sectionsTop, sectionsLeft = 4, 3
+	inputsRight := sectionsLeft + 1 + (width-2)/2 + 2
+	switch {
+	case y < sectionsTop || x < sectionsLeft || x >= width+6:
+		return -1
+	case y < sectionsTop+2+top: // label, its margin and the viewport
+		if x < inputsRight {
+			return 0
+		}
+		return 1
+	case y == sectionsTop+2+top:
+		return -1 // spacer
 	}
+	return 1
+}
 
-	_ = sectionWidth
+// TestCaseDetails renders a details pane showing test inputs, expected output,
+// and actual execution output side by side (or stacked if width is limited).
+// Each section is a viewport with line numbers scrolled by scroll; output
+// lines that differ from the expected ones are highlighted.
+// inspiration https://www.gh-dash.dev
+func TestCaseDetails(theme Theme, width int, height int, name string, testInputs []string, expectedOutput string, executionOutput string, scroll DetailsScroll) string {
 	halfSectionWidth := (width - 2) / 2
 
-	borderLeft := lipgloss.NewStyle().BorderLeft(true).BorderForeground(ColorTextMuted)
+	borderLeft := lipgloss.NewStyle().BorderLeft(true).BorderForeground(theme.Muted)
+
+	expectedLines := splitOutput(expectedOutput)
+	actualLines := splitOutput(executionOutput)
+	topRows, bottomRows := DetailsViewportRows(height, len(testInputs), len(expectedLines), len(actualLines))
 
 	// Build inputs section
-	inputsLabel := detailsSectionTitle.Render("󱋴 INPUTS")
-	inputsBody := detailsContent.Width(halfSectionWidth).Height(len(testInputs)).Render(strings.Join(testInputs, "\n"))
+	inputsLabel := detailsLabel(theme, "󱋴 INPUTS", scroll.Focused == 0, scroll.Inputs, topRows, len(testInputs))
+	inputsBody := detailsContent(theme).Width(halfSectionWidth).Height(topRows).Render(
+		detailsViewport(theme, testInputs, nil, scroll.Inputs, topRows, halfSectionWidth-4))
 	inputsSection := borderLeft.Render(lipgloss.JoinVertical(lipgloss.Left, inputsLabel, inputsBody))
 
 	// Build expected output section
-	expectedLabel := detailsSectionTitle.Render("󱋲 EXPECTED")
-	expectedBody := detailsContent.Width(halfSectionWidth).Height(len(testInputs)).Render(expectedOutput)
+	expectedLabel := detailsLabel(theme, "󱋲 EXPECTED", scroll.Focused == 1, scroll.Outputs, topRows, len(expectedLines))
+	expectedBody := detailsContent(theme).Width(halfSectionWidth).Height(topRows).Render(
+		detailsViewport(theme, expectedLines, nil, scroll.Outputs, topRows, halfSectionWidth-4))
 	expectedSection := lipgloss.JoinVertical(lipgloss.Left, expectedLabel, expectedBody)
 
 	// Build actual output section
-	actualLabel := detailsSectionTitle.Render(" OUTPUT")
-	actualBodyStyle := detailsContent.Width(width)
+	actualLabel := detailsLabel(theme, " OUTPUT", scroll.Focused == 1, scroll.Outputs, bottomRows, len(actualLines))
+	actualBodyStyle := detailsContent(theme).Width(width)
 	actualBody := ""
 	if executionOutput == "" {
-		actualBody = actualBodyStyle.Foreground(ColorTextMuted).Italic(true).Render("empty")
+		actualBody = actualBodyStyle.Foreground(theme.Muted).Italic(true).Render("empty")
 	} else {
-		actualBody = actualBodyStyle.Render(executionOutput)
+		actualBody = actualBodyStyle.Height(bottomRows).Render(
+			detailsViewport(theme, actualLines, expectedLines, scroll.Outputs, bottomRows, width-4))
 	}
 	actualSection := lipgloss.JoinVertical(lipgloss.Left, actualLabel, actualBody)
 
@@ -80,13 +133,62 @@ func TestCaseDetails(width int, height int, name string, testInputs []string, ex
 	)
 
 	// Wrap with name tag and container
-	nameTag := Tag(" "+name, lipgloss.Color("#ffffff"), ColorAccentBlue)
-
-	return detailsContainer.Render(
+	return detailsContainer(theme).Height(height).Render(
 		lipgloss.JoinVertical(
 			lipgloss.Center,
-			nameTag,
+			nameTag(theme, " "+name),
 			lipgloss.NewStyle().MarginTop(1).Render(sections),
 		),
 	)
 }
+
+// detailsLabel renders a section title, highlighted when focused, followed
+// by the visible line range when the content scrolls.
+func detailsLabel(theme Theme, title string, focused bool, offset, rows, total int) string {
+	style := detailsSectionTitle(theme)
+	if focused {
+		style = style.Foreground(theme.Warning)
+	}
+	if total > rows {
+		offset = clampOffset(offset, rows, total)
+		title += lipgloss.NewStyle().Foreground(theme.Muted).Bold(false).Render(fmt.Sprintf(" %d-%d/%d", offset+1, offset+rows, total))
+	}
+	return style.Render(title)
+}
+
+// detailsViewport renders rows lines from offset with line numbers, cut to
+// width. Lines that differ from compare at the same index are highlighted.
+func detailsViewport(theme Theme, lines, compare []string, offset, rows, width int) string {
+	// The gutter shows line numbers and mismatching output lines are
+	// highlighted.
+	lineNumber := lipgloss.NewStyle().Foreground(theme.Muted).Background(theme.Surface)
+	mismatch := lipgloss.NewStyle().Foreground(theme.Failure).Background(theme.Surface)
+
+	offset = clampOffset(offset, rows, len(lines))
+	end := min(offset+rows, len(lines))
+	gutter := len(strconv.Itoa(max(len(lines), 1)))
+
+	rendered := make([]string, 0, end-offset)
+	for i := offset; i < end; i++ {
+		number := lineNumber.Render(fmt.Sprintf("%*d ", gutter, i+1))
+		line := truncateLine(lines[i], max(width-gutter-1, 1))
+		if compare != nil && (i >= len(compare) || strings.TrimSpace(compare[i]) != strings.TrimSpace(lines[i])) {
+			line = mismatch.Render(line)
+		}
+		rendered = append(rendered, number+line)
+	}
+	return strings.Join(rendered, "\n")
+}
+
+// clampOffset keeps a viewport of rows lines within total lines.
+func clampOffset(offset, rows, total int) int {
+	return max(min(offset, total-rows), 0)
+}
+
+// splitOutput splits program output into lines; empty output has none.
+func splitOutput(output string) []string {
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
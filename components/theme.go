@@ -0,0 +1,203 @@
+package components
+
+import "github.com/charmbracelet/lipgloss"
+
+// Theme is the color palette every component renders with. Colors are named
+// by role so a palette reads well on a light or a dark background alike.
+type Theme struct {
+	Name string `json:"-"`
+
+	// Text is regular text and Muted secondary text and borders. Subtle is
+	// for content that is not there yet, such as pending cases.
+	Text   lipgloss.Color `json:"text"`
+	Muted  lipgloss.Color `json:"muted"`
+	Subtle lipgloss.Color `json:"subtle"`
+	// Accent marks titles, focus and tags; OnAccent is text drawn on top of
+	// the accent and the other filled colors.
+	Accent   lipgloss.Color `json:"accent"`
+	OnAccent lipgloss.Color `json:"on_accent"`
+	// Inverse is text drawn on the alert color and on menu selections.
+	Inverse lipgloss.Color `json:"inverse"`
+
+	Success lipgloss.Color `json:"success"`
+	Failure lipgloss.Color `json:"failure"`
+	Alert   lipgloss.Color `json:"alert"`
+	// Warning highlights running work, regressions and focused labels.
+	Warning lipgloss.Color `json:"warning"`
+
+	// Surface is the background of content blocks such as test outputs.
+	Surface lipgloss.Color `json:"surface"`
+	// Selection and SelectionText color the selected row.
+	Selection     lipgloss.Color `json:"selection"`
+	SelectionText lipgloss.Color `json:"selection_text"`
+
+	PendingFG lipgloss.Color `json:"pending_fg"`
+	PendingBG lipgloss.Color `json:"pending_bg"`
+	// HeaderFG and HeaderBG color the column headers of lists.
+	HeaderFG lipgloss.Color `json:"header_fg"`
+	HeaderBG lipgloss.Color `json:"header_bg"`
+
+	// StatusText, LanguageBadge and FilenameBadge color the footer.
+	StatusText    lipgloss.Color `json:"status_text"`
+	LanguageBadge lipgloss.Color `json:"language_badge"`
+	FilenameBadge lipgloss.Color `json:"filename_badge"`
+}
+
+// DarkTheme is the original Défi palette, tuned for dark terminals.
+var DarkTheme = Theme{
+	Name:          "dark",
+	Text:          "#cdd6f4",
+	Muted:         "#9399b2",
+	Subtle:        "#717171ff",
+	Accent:        "#89b4fa",
+	OnAccent:      "#FFFFFF",
+	Inverse:       "#313130",
+	Success:       "#a6e3a1",
+	Failure:       "#f38ba8",
+	Alert:         "#ff4e86",
+	Warning:       "#f9e2af",
+	Surface:       "#313130",
+	Selection:     "#d9d9d9",
+	SelectionText: "#313130",
+	PendingFG:     "#808080",
+	PendingBG:     "#1c1c1c",
+	HeaderFG:      "#585858ff",
+	HeaderBG:      "#b4d3b1ff",
+	StatusText:    "#b6bca8",
+	LanguageBadge: "#b149e8",
+	FilenameBadge: "#6b21e8",
+}
+
+// LightTheme is for terminals with a light background.
+var LightTheme = Theme{
+	Name:          "light",
+	Text:          "#1f2328",
+	Muted:         "#57606a",
+	Subtle:        "#8c959f",
+	Accent:        "#0969da",
+	OnAccent:      "#ffffff",
+	Inverse:       "#ffffff",
+	Success:       "#1a7f37",
+	Failure:       "#cf222e",
+	Alert:         "#d1245a",
+	Warning:       "#9a6700",
+	Surface:       "#eaeef2",
+	Selection:     "#d0d7de",
+	SelectionText: "#1f2328",
+	PendingFG:     "#6e7781",
+	PendingBG:     "#f6f8fa",
+	HeaderFG:      "#24292f",
+	HeaderBG:      "#c8e6c9",
+	StatusText:    "#424a53",
+	LanguageBadge: "#8250df",
+	FilenameBadge: "#5a32a3",
+}
+
+// HighContrastTheme uses pure black and white with saturated status colors.
+var HighContrastTheme = Theme{
+	Name:          "high-contrast",
+	Text:          "#ffffff",
+	Muted:         "#d0d0d0",
+	Subtle:        "#a8a8a8",
+	Accent:        "#00afff",
+	OnAccent:      "#000000",
+	Inverse:       "#000000",
+	Success:       "#00ff5f",
+	Failure:       "#ff5f5f",
+	Alert:         "#ff005f",
+	Warning:       "#ffff00",
+	Surface:       "#000000",
+	Selection:     "#ffffff",
+	SelectionText: "#000000",
+	PendingFG:     "#d0d0d0",
+	PendingBG:     "#262626",
+	HeaderFG:      "#000000",
+	HeaderBG:      "#ffffff",
+	StatusText:    "#ffffff",
+	LanguageBadge: "#d75fff",
+	FilenameBadge: "#87afff",
+}
+
+// catppuccinPalette holds the Catppuccin colors a theme is built from.
+// See https://catppuccin.com/palette.
+type catppuccinPalette struct {
+	text, subtext0, overlay2, overlay0      lipgloss.Color
+	surface0, surface1, base, mantle, crust lipgloss.Color
+	blue, green, red, maroon, yellow, mauve lipgloss.Color
+	lavender                                lipgloss.Color
+}
+
+func catppuccin(name string, p catppuccinPalette) Theme {
+	return Theme{
+		Name:          name,
+		Text:          p.text,
+		Muted:         p.overlay2,
+		Subtle:        p.overlay0,
+		Accent:        p.blue,
+		OnAccent:      p.base,
+		Inverse:       p.base,
+		Success:       p.green,
+		Failure:       p.red,
+		Alert:         p.maroon,
+		Warning:       p.yellow,
+		Surface:       p.surface0,
+		Selection:     p.surface1,
+		SelectionText: p.text,
+		PendingFG:     p.subtext0,
+		PendingBG:     p.mantle,
+		HeaderFG:      p.subtext0,
+		HeaderBG:      p.crust,
+		StatusText:    p.subtext0,
+		LanguageBadge: p.mauve,
+		FilenameBadge: p.lavender,
+	}
+}
+
+// The Catppuccin flavors, from the lightest to the darkest.
+var (
+	CatppuccinLatte = catppuccin("catppuccin-latte", catppuccinPalette{
+		text: "#4c4f69", subtext0: "#6c6f85", overlay2: "#7c7f93", overlay0: "#9ca0b0",
+		surface0: "#ccd0da", surface1: "#bcc0cc", base: "#eff1f5", mantle: "#e6e9ef", crust: "#dce0e8",
+		blue: "#1e66f5", green: "#40a02b", red: "#d20f39", maroon: "#e64553", yellow: "#df8e1d",
+		mauve: "#8839ef", lavender: "#7287fd",
+	})
+	CatppuccinFrappe = catppuccin("catppuccin-frappe", catppuccinPalette{
+		text: "#c6d0f5", subtext0: "#a5adce", overlay2: "#949cbb", overlay0: "#737994",
+		surface0: "#414559", surface1: "#51576d", base: "#303446", mantle: "#292c3c", crust: "#232634",
+		blue: "#8caaee", green: "#a6d189", red: "#e78284", maroon: "#ea999c", yellow: "#e5c890",
+		mauve: "#ca9ee6", lavender: "#babbf1",
+	})
+	CatppuccinMacchiato = catppuccin("catppuccin-macchiato", catppuccinPalette{
+		text: "#cad3f5", subtext0: "#a5adcb", overlay2: "#939ab7", overlay0: "#6e738d",
+		surface0: "#363a4f", surface1: "#494d64", base: "#24273a", mantle: "#1e2030", crust: "#181926",
+		blue: "#8aadf4", green: "#a6da95", red: "#ed8796", maroon: "#ee99a0", yellow: "#eed49f",
+		mauve: "#c6a0f6", lavender: "#b7bdf8",
+	})
+	CatppuccinMocha = catppuccin("catppuccin-mocha", catppuccinPalette{
+		text: "#cdd6f4", subtext0: "#a6adc8", overlay2: "#9399b2", overlay0: "#6c7086",
+		surface0: "#313244", surface1: "#45475a", base: "#1e1e2e", mantle: "#181825", crust: "#11111b",
+		blue: "#89b4fa", green: "#a6e3a1", red: "#f38ba8", maroon: "#eba0ac", yellow: "#f9e2af",
+		mauve: "#cba6f7", lavender: "#b4befe",
+	})
+)
+
+// Themes lists the built-in themes.
+var Themes = []Theme{
+	DarkTheme,
+	LightTheme,
+	HighContrastTheme,
+	CatppuccinLatte,
+	CatppuccinFrappe,
+	CatppuccinMacchiato,
+	CatppuccinMocha,
+}
+
+// ThemeByName returns the built-in theme called name.
+func ThemeByName(name string) (Theme, bool) {
+	for _, t := range Themes {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Theme{}, false
+}
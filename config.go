@@ -4,31 +4,81 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pedrohff/defi/components"
+	"github.com/pedrohff/defi/view"
 )
 
-const usageMessage = "usage: defi [--interval N] [--once] [path|pattern]"
+const usageMessage = "usage: defi [--interval D] [--debounce D] [--once] [--all] [--record] [--layout L] [--theme T] [path|pattern]"
+
+// defaultDebounce is how long Défi waits for further saves before running.
+const defaultDebounce = 100 * time.Millisecond
 
 type appConfig struct {
 	spec         watchSpec
 	interval     time.Duration
+	debounce     time.Duration
 	once         bool
+	all          bool
+	poll         bool
+	record       bool
+	timeLimit    time.Duration
 	compileFlags []string
+	checker      string
+	reference    string
+	validator    string
+	// historyDir stores the run history; empty disables it.
+	historyDir string
+	// settingsPath is the user config file that remembers preferences such
+	// as the layout; empty leaves it untouched.
+	settingsPath string
+	layout       view.Layout
+	// keys rebinds keys by binding name.
+	keys map[string][]string
+	// noMouse leaves the mouse to the terminal, for selecting text.
+	noMouse bool
+	theme   components.Theme
 }
 
 func parseAppConfig(args []string) (appConfig, string, error) {
 	fs := flag.NewFlagSet("defi", flag.ContinueOnError)
-	intervalFlag := fs.Int("interval", 1, "Polling interval in seconds")
+	intervalFlag := fs.String("interval", "1s", "Polling interval as a duration (250ms, 2s) or seconds")
+	debounceFlag := fs.Duration("debounce", defaultDebounce, "Wait this long after a change so rapid saves coalesce into one run")
 	onceFlag := fs.Bool("once", false, "Run tests once and exit")
+	allFlag := fs.Bool("all", false, "Test every matching file once, print a summary table and exit")
+	recursiveFlag := fs.Bool("recursive", false, "Watch subdirectories of a directory or pattern too")
+	var ignorePatterns []string
+	fs.Func("ignore", "Ignore files matching a gitignore-style pattern (repeatable)", func(value string) error {
+		ignorePatterns = append(ignorePatterns, value)
+		return nil
+	})
+	pollFlag := fs.Bool("poll", false, "Poll for changes instead of using file system notifications")
+	recordFlag := fs.Bool("record", false, "Write actual output into cases with an empty or ? OUTPUT section")
+	timeLimitFlag := fs.Duration("time-limit", 0, "Per-case time limit when the source declares none (e.g. 2s)")
 	compileFlagsFlag := fs.String("compile-flags", "", "Override compiler flags (space-separated)")
+	checkerFlag := fs.String("checker", "", "Output checker: exact, tokens, float[:EPS] or a checker program")
+	refFlag := fs.String("ref", "", "Reference solution used when shrinking a failing case")
+	validatorFlag := fs.String("validator", "", "Input validator used when shrinking a failing case")
+	noHistoryFlag := fs.Bool("no-history", false, "Do not record runs in "+defaultHistoryDir+"/"+historyFileName)
+	noMouseFlag := fs.Bool("no-mouse", false, "Disable mouse support so the terminal can select text")
+	layoutFlag := fs.String("layout", "", "Start in a layout: "+layoutNames()+" (remembered from the last session by default)")
+	themeFlag := fs.String("theme", "", "Color theme: "+themeNames()+" or a theme from the config file")
 
 	if err := fs.Parse(args); err != nil {
 		return appConfig{}, "", err
 	}
 
-	if *intervalFlag <= 0 {
-		return appConfig{}, "", fmt.Errorf("interval must be greater than zero")
+	interval, err := parseInterval(*intervalFlag)
+	if err != nil {
+		return appConfig{}, "", err
+	}
+
+	if *debounceFlag < 0 {
+		return appConfig{}, "", fmt.Errorf("debounce must not be negative")
 	}
 
 	remaining := fs.Args()
@@ -40,12 +90,30 @@ func parseAppConfig(args []string) (appConfig, string, error) {
 	if err != nil {
 		return appConfig{}, "", err
 	}
+	spec.recursive = spec.recursive || *recursiveFlag
+	spec.ignore = ignorePatterns
 
 	cfg := appConfig{
 		spec:         spec,
-		interval:     time.Duration(*intervalFlag) * time.Second,
-		once:         *onceFlag,
+		interval:     interval,
+		debounce:     *debounceFlag,
+		once:         *onceFlag || *allFlag,
+		all:          *allFlag,
+		poll:         *pollFlag,
+		record:       *recordFlag,
+		timeLimit:    *timeLimitFlag,
 		compileFlags: strings.Fields(*compileFlagsFlag),
+		checker:      *checkerFlag,
+		reference:    *refFlag,
+		validator:    *validatorFlag,
+		noMouse:      *noMouseFlag,
+	}
+	if !*noHistoryFlag {
+		cfg.historyDir = defaultHistoryDir
+	}
+
+	if err := applyUserSettings(&cfg, *layoutFlag, *themeFlag); err != nil {
+		return appConfig{}, "", err
 	}
 
 	initialPath := ""
@@ -61,3 +129,63 @@ func parseAppConfig(args []string) (appConfig, string, error) {
 
 	return cfg, initialPath, nil
 }
+
+// applyUserSettings loads the user config file into cfg: the settings path,
+// the saved layout, the key overrides and the theme. Non-empty layout and
+// theme override the saved ones for this session.
+func applyUserSettings(cfg *appConfig, layout, theme string) error {
+	settings := userSettings{Layout: layout, Theme: theme}
+	if path, err := userSettingsPath(); err == nil {
+		cfg.settingsPath = path
+		if settings, err = loadUserSettings(path); err != nil {
+			return err
+		}
+		if layout != "" {
+			settings.Layout = layout
+		}
+		if theme != "" {
+			settings.Theme = theme
+		}
+	}
+	if settings.Layout != "" {
+		parsed, ok := view.ParseLayout(settings.Layout)
+		if !ok {
+			return fmt.Errorf("unknown layout %q (want %s)", settings.Layout, layoutNames())
+		}
+		cfg.layout = parsed
+	}
+	if _, err := newKeyMap(settings.Keys); err != nil {
+		return fmt.Errorf("invalid config %s: %w", cfg.settingsPath, err)
+	}
+	cfg.keys = settings.Keys
+	var err error
+	cfg.theme, err = resolveTheme(settings.Theme, settings.Themes, lipgloss.HasDarkBackground)
+	return err
+}
+
+// layoutNames lists the layouts accepted by --layout.
+func layoutNames() string {
+	names := make([]string, len(view.Layouts))
+	for i, l := range view.Layouts {
+		names[i] = string(l)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseInterval accepts Go duration strings ("250ms", "2s") as well as a bare
+// number of seconds for compatibility with older invocations.
+func parseInterval(value string) (time.Duration, error) {
+	var interval time.Duration
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		interval = time.Duration(secs * float64(time.Second))
+	} else if d, err := time.ParseDuration(value); err == nil {
+		interval = d
+	} else {
+		return 0, fmt.Errorf("invalid interval %q", value)
+	}
+
+	if interval <= 0 {
+		return 0, fmt.Errorf("interval must be greater than zero")
+	}
+	return interval, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pedrohff/defi/components"
+	"github.com/pedrohff/defi/view"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"1", time.Second},
+		{"0.5", 500 * time.Millisecond},
+		{"250ms", 250 * time.Millisecond},
+		{"2s", 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		got, err := parseInterval(tt.value)
+		if err != nil {
+			t.Fatalf("parseInterval(%q) returned error: %v", tt.value, err)
+		}
+		if got != tt.want {
+			t.Fatalf("parseInterval(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"0", "-1s", "soon"} {
+		if _, err := parseInterval(value); err == nil {
+			t.Fatalf("expected parseInterval(%q) to fail", value)
+		}
+	}
+}
+
+func TestApplyUserSettingsLoadsTheConfigFile(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	path := filepath.Join(configDir, "defi", "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"layout": "list", "theme": "catppuccin-mocha", "keys": {"accept": ["A"]}}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg appConfig
+	if err := applyUserSettings(&cfg, "", ""); err != nil {
+		t.Fatalf("applyUserSettings: %v", err)
+	}
+	if cfg.settingsPath != path || cfg.layout != view.LayoutListOnly || cfg.theme != components.CatppuccinMocha {
+		t.Fatalf("expected the saved settings, got %q, %s, %q", cfg.settingsPath, cfg.layout, cfg.theme.Name)
+	}
+	if !reflect.DeepEqual(cfg.keys, map[string][]string{"accept": {"A"}}) {
+		t.Fatalf("expected the key overrides, got %v", cfg.keys)
+	}
+
+	if err := applyUserSettings(&cfg, "maximized", "light"); err != nil {
+		t.Fatalf("applyUserSettings: %v", err)
+	}
+	if cfg.layout != view.LayoutMaximized || cfg.theme != components.LightTheme {
+		t.Fatalf("expected the flags to override the config, got %s, %q", cfg.layout, cfg.theme.Name)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffOp tells whether a diff line is shared, removed or added.
+type diffOp byte
+
+const (
+	diffEqual  diffOp = ' '
+	diffDelete diffOp = '-'
+	diffInsert diffOp = '+'
+)
+
+// diffLine is one line of a line diff.
+type diffLine struct {
+	Op   diffOp
+	Text string
+}
+
+// maxDiffCells bounds the longest-common-subsequence table; larger inputs
+// are diffed as a whole replacement.
+const maxDiffCells = 16 * 1024 * 1024
+
+// lineDiff computes the lines removed from a and added in b, keeping a
+// longest common subsequence of lines in place.
+func lineDiff(a, b []string) []diffLine {
+	// Shared prefixes and suffixes need no table.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var out []diffLine
+	for _, line := range a[:prefix] {
+		out = append(out, diffLine{diffEqual, line})
+	}
+	out = append(out, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		out = append(out, diffLine{diffEqual, line})
+	}
+	return out
+}
+
+func diffMiddle(a, b []string) []diffLine {
+	var out []diffLine
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			out = append(out, diffLine{diffDelete, line})
+		}
+		for _, line := range b {
+			out = append(out, diffLine{diffInsert, line})
+		}
+		return out
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, diffLine{diffEqual, a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[(i+1)*width+j] >= lcs[i*width+j+1]):
+			out = append(out, diffLine{diffDelete, a[i]})
+			i++
+		default:
+			out = append(out, diffLine{diffInsert, b[j]})
+			j++
+		}
+	}
+	return out
+}
+
+// splitSource splits file content into lines without a trailing empty one.
+func splitSource(content []byte) []string {
+	text := strings.TrimSuffix(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// writeUnifiedDiff prints the changes as hunks with context lines of
+// surrounding code, like `diff -u`.
+func writeUnifiedDiff(w io.Writer, fromName, toName string, lines []diffLine, context int) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range diffHunks(lines, context) {
+		// An empty side is numbered after the line it follows.
+		if h.fromCount == 0 {
+			h.fromLine--
+		}
+		if h.toCount == 0 {
+			h.toLine--
+		}
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", h.fromLine, h.fromCount, h.toLine, h.toCount)
+		for _, l := range lines[h.start:h.end] {
+			fmt.Fprintf(w, "%c%s\n", l.Op, l.Text)
+		}
+	}
+}
+
+// diffHunk is a run of changed lines with their context, as indexes into a
+// diff and the line numbers it covers on either side.
+type diffHunk struct {
+	start, end          int
+	fromLine, fromCount int
+	toLine, toCount     int
+}
+
+// diffHunks groups changes that are at most 2*context lines apart.
+func diffHunks(lines []diffLine, context int) []diffHunk {
+	var hunks []diffHunk
+	for i := 0; i < len(lines); i++ {
+		if lines[i].Op == diffEqual {
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != diffEqual {
+				end++
+				continue
+			}
+			// Stop once the equal run is too long to bridge.
+			run := end
+			for run < len(lines) && lines[run].Op == diffEqual {
+				run++
+			}
+			if run == len(lines) || run-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = run
+		}
+		hunks = append(hunks, diffHunk{start: start, end: end})
+		i = end - 1
+	}
+
+	// Fill in line numbers by walking the diff once.
+	fromLine, toLine, h := 1, 1, 0
+	for i, l := range lines {
+		if h < len(hunks) && i == hunks[h].start {
+			hunks[h].fromLine, hunks[h].toLine = fromLine, toLine
+		}
+		if h < len(hunks) && i >= hunks[h].start && i < hunks[h].end {
+			if l.Op != diffInsert {
+				hunks[h].fromCount++
+			}
+			if l.Op != diffDelete {
+				hunks[h].toCount++
+			}
+		}
+		if l.Op != diffInsert {
+			fromLine++
+		}
+		if l.Op != diffDelete {
+			toLine++
+		}
+		if h < len(hunks) && i == hunks[h].end-1 {
+			h++
+		}
+	}
+	return hunks
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	a := []string{"a", "b", "c", "d"}
+	b := []string{"a", "c", "x", "d", "e"}
+
+	var got []string
+	for _, l := range lineDiff(a, b) {
+		got = append(got, string(l.Op)+l.Text)
+	}
+	want := []string{" a", "-b", " c", "+x", " d", "+e"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteUnifiedDiffGroupsHunks(t *testing.T) {
+	var a []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, strings.Repeat("x", i))
+	}
+	b := append([]string{}, a...)
+	b[1] = "changed"
+	b = append(b[:15], b[16:]...)
+
+	var out bytes.Buffer
+	writeUnifiedDiff(&out, "old", "new", lineDiff(a, b), 2)
+	want := `--- old
++++ new
+@@ -1,4 +1,4 @@
+ x
+-xx
++changed
+ xxx
+ xxxx
+@@ -14,5 +14,4 @@
+ xxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxx
+-xxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxxx
+`
+	if out.String() != want {
+		t.Fatalf("unexpected diff:\n%s", out.String())
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// externalTestsDir returns the directory holding file based cases for a
+// source, e.g. "a.tests" next to "a.cpp". Each case is a pair of "N.in" and
+// "N.out" files; a missing ".out" marks the case as pending.
+func externalTestsDir(sourcePath string) string {
+	stem := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
+	return filepath.Join(filepath.Dir(sourcePath), stem+".tests")
+}
+
+// loadTestSuite returns the inline defiprompt cases of a source followed by
+// its external test files.
+func loadTestSuite(sourcePath string) ([]PromptCase, error) {
+	data, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", sourcePath, err)
+	}
+
+	cases, err := parsePromptContent(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	external, err := loadExternalCases(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	cases = append(cases, external...)
+
+	// An empty defiprompt block is a valid, freshly scaffolded suite.
+	if len(cases) == 0 && !strings.Contains(string(data), promptMarker) {
+		return nil, fmt.Errorf("no defiprompt blocks or external tests found for %q", sourcePath)
+	}
+
+	return cases, nil
+}
+
+// loadExternalCases reads every "N.in" file in the external tests directory,
+// ordered numerically.
+func loadExternalCases(sourcePath string) ([]PromptCase, error) {
+	dir := externalTestsDir(sourcePath)
+	inputs, err := externalInputFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []PromptCase
+	for _, inPath := range inputs {
+		in, err := readCaseFile(inPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(in) == 0 {
+			return nil, fmt.Errorf("external test %q has no input", inPath)
+		}
+
+		c := PromptCase{Inputs: in, External: inPath}
+		out, err := readCaseFile(strings.TrimSuffix(inPath, ".in") + ".out")
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			c.Pending = true
+		case err != nil:
+			return nil, err
+		case len(out) == 0 || (len(out) == 1 && out[0] == "?"):
+			c.Pending = true
+		default:
+			c.Outputs = out
+		}
+		cases = append(cases, c)
+	}
+
+	return cases, nil
+}
+
+func externalInputFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %q: %w", dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".in" {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		ni, errI := strconv.Atoi(strings.TrimSuffix(names[i], ".in"))
+		nj, errJ := strconv.Atoi(strings.TrimSuffix(names[j], ".in"))
+		if errI == nil && errJ == nil && ni != nj {
+			return ni < nj
+		}
+		return names[i] < names[j]
+	})
+
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+	}
+	return paths, nil
+}
+
+// readCaseFile returns the non-blank, trimmed lines of a test file, matching
+// how defiprompt sections are read.
+func readCaseFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// addExternalCase writes a new numbered case to the external tests directory.
+func addExternalCase(sourcePath string, inputs, outputs []string) (string, error) {
+	dir := externalTestsDir(sourcePath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %q: %w", dir, err)
+	}
+
+	existing, err := externalInputFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	next := 1
+	for _, path := range existing {
+		if n, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(path), ".in")); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+
+	inPath := filepath.Join(dir, fmt.Sprintf("%d.in", next))
+	if err := writeCaseFile(inPath, inputs); err != nil {
+		return "", err
+	}
+	if len(outputs) > 0 {
+		if err := writeCaseFile(strings.TrimSuffix(inPath, ".in")+".out", outputs); err != nil {
+			return "", err
+		}
+	}
+	return inPath, nil
+}
+
+func writeCaseFile(path string, lines []string) error {
+	content := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %q: %w", path, err)
+	}
+	return nil
+}
+
+// writeCaseOutputs stores outputs for the given suite case indices, rewriting
+// defiprompt blocks in place or the matching ".out" files.
+func writeCaseOutputs(sourcePath string, outputs map[int][]string) error {
+	cases, err := loadTestSuite(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	inline := make(map[int][]string)
+	for idx, lines := range outputs {
+		if idx < 0 || idx >= len(cases) {
+			return fmt.Errorf("case %d not found", idx+1)
+		}
+		if c := cases[idx]; c.External != "" {
+			if err := writeCaseFile(strings.TrimSuffix(c.External, ".in")+".out", lines); err != nil {
+				return err
+			}
+			continue
+		}
+		inline[idx] = lines
+	}
+
+	if len(inline) == 0 {
+		return nil
+	}
+	return writePromptOutputs(sourcePath, inline)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pedrohff/defi/components"
+)
+
+// menuKind tells what a footer menu chooses.
+type menuKind int
+
+const (
+	// menuFiles switches between the watched files.
+	menuFiles menuKind = iota
+	// menuCompileFlags picks the compiler flags of the active language.
+	menuCompileFlags
+)
+
+// compileFlagPresets are offered by the language menu after the language's
+// default flags.
+var compileFlagPresets = map[string][][]string{
+	".cpp": {
+		{"-std=c++17", "-O2"},
+		{"-std=c++20", "-O2"},
+		{"-std=c++17", "-g", "-fsanitize=address,undefined"},
+	},
+}
+
+// footerMenu is a menu opened by clicking a footer tag.
+type footerMenu struct {
+	kind  menuKind
+	title string
+	items []string
+	// paths and flags hold the choice behind each item, for menuFiles and
+	// menuCompileFlags respectively. nil flags stand for the default.
+	paths    []string
+	flags    [][]string
+	selected int
+	marked   int // the current choice
+	theme    components.Theme
+}
+
+// newFileMenu lists the watched files, marking the active one.
+func newFileMenu(theme components.Theme, files []string, active string) *footerMenu {
+	items := make([]string, len(files))
+	for i, path := range files {
+		items[i] = footerFilename(path)
+	}
+	current := max(slices.Index(files, active), 0)
+	return &footerMenu{
+		kind:     menuFiles,
+		title:    "Files",
+		items:    items,
+		paths:    files,
+		selected: current,
+		marked:   current,
+		theme:    theme,
+	}
+}
+
+// newCompileFlagsMenu lists the flag presets of ext's language, marking the
+// flags in use.
+func newCompileFlagsMenu(theme components.Theme, ext string, current []string) *footerMenu {
+	flags := append([][]string{nil}, compileFlagPresets[ext]...)
+	items := make([]string, len(flags))
+	marked := 0
+	for i, f := range flags {
+		if f == nil {
+			items[i] = "default " + strings.Join(defaultCompileFlags[ext], " ")
+			continue
+		}
+		items[i] = strings.Join(f, " ")
+		if slices.Equal(f, current) {
+			marked = i
+		}
+	}
+	return &footerMenu{
+		kind:     menuCompileFlags,
+		title:    "Compile flags",
+		items:    items,
+		flags:    flags,
+		selected: marked,
+		marked:   marked,
+		theme:    theme,
+	}
+}
+
+// move moves the cursor by delta items, stopping at either end.
+func (f *footerMenu) move(delta int) {
+	f.selected = max(min(f.selected+delta, len(f.items)-1), 0)
+}
+
+// View renders the menu, centered over the body by the main view.
+func (f *footerMenu) View(width, height int) string {
+	return components.Menu(f.theme, f.title, f.items, f.selected, f.marked, "enter choose • esc close")
+}
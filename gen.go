@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const genUsageMessage = "usage: defi gen <source> [--seed N] [--count N] [--set name=value]... [--spec GEN] [--external]"
+
+// runGenCommand expands the GEN directive of a source into test inputs.
+func runGenCommand(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("defi gen", flag.ContinueOnError)
+	seedFlag := fs.Uint64("seed", 1, "Seed of the first input")
+	countFlag := fs.Int("count", 1, "Number of inputs, using consecutive seeds")
+	specFlag := fs.String("spec", "", "GEN spec text to use instead of the source's GEN directive, e.g. 'n=int(1,10)'")
+	externalFlag := fs.Bool("external", false, "Save the inputs as pending external tests instead of printing them")
+	overrides := make(map[string]int64)
+	fs.Func("set", "Fix a GEN variable, e.g. n=200000 (repeatable)", func(value string) error {
+		name, raw, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("expected name=value, got %q", value)
+		}
+		n, err := parseGenNumber(raw)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %q", name, raw)
+		}
+		overrides[strings.TrimSpace(name)] = n
+		return nil
+	})
+
+	// Accept flags both before and after the source.
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() == 0 {
+		return usageErrorf("missing source file")
+	}
+	source := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *countFlag < 1 {
+		return usageErrorf("count must be at least 1")
+	}
+
+	var (
+		spec *genSpec
+		err  error
+	)
+	if *specFlag != "" {
+		spec, err = parseGenSpec(*specFlag)
+	} else if spec, err = loadGenSpec(source); err == nil && spec == nil {
+		err = fmt.Errorf("%s has no GEN directive", source)
+	}
+	if err != nil {
+		return err
+	}
+	for name := range overrides {
+		if !slices.Contains(spec.Names(), name) {
+			return fmt.Errorf("GEN has no variable %s", name)
+		}
+	}
+
+	for i := 0; i < *countFlag; i++ {
+		seed := *seedFlag + uint64(i)
+		inputs, err := spec.Generate(seed, overrides)
+		if err != nil {
+			return err
+		}
+
+		if *externalFlag {
+			path, err := addExternalCase(source, inputs, nil)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "Wrote seed %d to %s\n", seed, path)
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		for _, line := range inputs {
+			fmt.Fprintln(stdout, line)
+		}
+	}
+	return nil
+}
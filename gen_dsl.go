@@ -0,0 +1,584 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// genDirective declares a random input generator in the defiprompt header,
+// e.g. "GEN: n=int(1,10); a=array(n,int(-100,100))". Every statement prints
+// its value as one or more input lines; names starting with "_" are only
+// bound, not printed. Several GEN lines are joined in order.
+const genDirective = "GEN:"
+
+// genFuncArity lists the generator functions and their argument counts;
+// -1 means one or more.
+var genFuncArity = map[string]int{
+	"int":    2, // int(lo, hi): uniform integer in [lo, hi]
+	"array":  2, // array(len, expr): len values of expr on one line
+	"lines":  2, // lines(count, expr): count values of expr, one per line
+	"perm":   1, // perm(n): a permutation of 1..n on one line
+	"string": 2, // string(len, "a-z"): random characters from the alphabet
+	"choice": -1,
+}
+
+// genSpec is a parsed GEN program.
+type genSpec struct {
+	stmts []genStmt
+}
+
+type genStmt struct {
+	name string
+	expr genExpr
+}
+
+// genValue is either a number or rendered input lines.
+type genValue struct {
+	num   int64
+	isNum bool
+	lines []string
+}
+
+func (v genValue) render() []string {
+	if v.isNum {
+		return []string{strconv.FormatInt(v.num, 10)}
+	}
+	return v.lines
+}
+
+// genMaxSize bounds the values a program generates in total, counting the
+// elements of every array, lines, perm and string, so that a huge count
+// fails instead of exhausting memory.
+const genMaxSize = 10_000_000
+
+type genEnv struct {
+	rng  *rand.Rand
+	vars map[string]genValue
+	// size counts the values generated so far, up to genMaxSize.
+	size int64
+}
+
+type genExpr interface {
+	eval(env *genEnv) (genValue, error)
+}
+
+// loadGenSpec returns the GEN program declared in a source, or nil when it
+// declares none.
+func loadGenSpec(sourcePath string) (*genSpec, error) {
+	data, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", sourcePath, err)
+	}
+
+	lines, err := promptHeaderLines(string(data))
+	if err != nil {
+		return nil, err
+	}
+	var parts []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, genDirective) {
+			parts = append(parts, strings.TrimSpace(strings.TrimPrefix(line, genDirective)))
+		}
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	return parseGenSpec(strings.Join(parts, ";"))
+}
+
+// Generate expands the program with a RNG seeded by seed. overrides fixes
+// the value of named statements, e.g. to force a large n.
+func (g *genSpec) Generate(seed uint64, overrides map[string]int64) ([]string, error) {
+	env := &genEnv{
+		rng:  rand.New(rand.NewPCG(seed, 0x646566692d67656e)),
+		vars: make(map[string]genValue),
+	}
+
+	var out []string
+	for _, stmt := range g.stmts {
+		value, ok := genValue{}, false
+		if stmt.name != "" {
+			var n int64
+			if n, ok = overrides[stmt.name]; ok {
+				value = genValue{num: n, isNum: true}
+			}
+		}
+		if !ok {
+			var err error
+			if value, err = stmt.expr.eval(env); err != nil {
+				return nil, err
+			}
+		}
+
+		if stmt.name != "" {
+			env.vars[stmt.name] = value
+		}
+		if !strings.HasPrefix(stmt.name, "_") {
+			out = append(out, value.render()...)
+		}
+	}
+	return out, nil
+}
+
+// Names lists the variables bound by the program.
+func (g *genSpec) Names() []string {
+	var names []string
+	for _, stmt := range g.stmts {
+		if stmt.name != "" {
+			names = append(names, stmt.name)
+		}
+	}
+	return names
+}
+
+type genNum struct{ value int64 }
+
+func (e genNum) eval(*genEnv) (genValue, error) {
+	return genValue{num: e.value, isNum: true}, nil
+}
+
+type genStr struct{ value string }
+
+func (e genStr) eval(*genEnv) (genValue, error) {
+	return genValue{lines: []string{e.value}}, nil
+}
+
+type genVar struct{ name string }
+
+func (e genVar) eval(env *genEnv) (genValue, error) {
+	return env.vars[e.name], nil
+}
+
+type genBinary struct {
+	op          byte
+	left, right genExpr
+}
+
+func (e genBinary) eval(env *genEnv) (genValue, error) {
+	l, err := evalInt(env, e.left)
+	if err != nil {
+		return genValue{}, err
+	}
+	r, err := evalInt(env, e.right)
+	if err != nil {
+		return genValue{}, err
+	}
+
+	var n int64
+	overflow := false
+	switch e.op {
+	case '+':
+		n = l + r
+		overflow = (r > 0 && n < l) || (r < 0 && n > l)
+	case '-':
+		n = l - r
+		overflow = (r > 0 && n > l) || (r < 0 && n < l)
+	case '*':
+		n = l * r
+		overflow = l != 0 && (n/l != r || (l == -1 && r == math.MinInt64))
+	case '/', '%':
+		if r == 0 {
+			return genValue{}, fmt.Errorf("GEN: division by zero")
+		}
+		n = l / r
+		overflow = l == math.MinInt64 && r == -1
+		if e.op == '%' {
+			n, overflow = l%r, false
+		}
+	}
+	if overflow {
+		return genValue{}, fmt.Errorf("GEN: %d %c %d overflows", l, e.op, r)
+	}
+	return genValue{num: n, isNum: true}, nil
+}
+
+type genCall struct {
+	name string
+	args []genExpr
+}
+
+func (e genCall) eval(env *genEnv) (genValue, error) {
+	switch e.name {
+	case "int":
+		lo, err := evalInt(env, e.args[0])
+		if err != nil {
+			return genValue{}, err
+		}
+		hi, err := evalInt(env, e.args[1])
+		if err != nil {
+			return genValue{}, err
+		}
+		if lo > hi {
+			return genValue{}, fmt.Errorf("GEN: int(%d, %d) has an empty range", lo, hi)
+		}
+		if hi-lo+1 <= 0 {
+			return genValue{}, fmt.Errorf("GEN: int(%d, %d) range is too large", lo, hi)
+		}
+		return genValue{num: lo + env.rng.Int64N(hi-lo+1), isNum: true}, nil
+
+	case "array", "lines":
+		n, err := evalCount(env, e.name, e.args[0])
+		if err != nil {
+			return genValue{}, err
+		}
+		var items []string
+		for i := int64(0); i < n; i++ {
+			v, err := e.args[1].eval(env)
+			if err != nil {
+				return genValue{}, err
+			}
+			items = append(items, v.render()...)
+		}
+		if e.name == "lines" {
+			return genValue{lines: items}, nil
+		}
+		return genValue{lines: []string{strings.Join(items, " ")}}, nil
+
+	case "perm":
+		n, err := evalCount(env, e.name, e.args[0])
+		if err != nil {
+			return genValue{}, err
+		}
+		items := make([]string, n)
+		for i, p := range env.rng.Perm(int(n)) {
+			items[i] = strconv.Itoa(p + 1)
+		}
+		return genValue{lines: []string{strings.Join(items, " ")}}, nil
+
+	case "string":
+		n, err := evalCount(env, e.name, e.args[0])
+		if err != nil {
+			return genValue{}, err
+		}
+		lit, ok := e.args[1].(genStr)
+		if !ok {
+			return genValue{}, fmt.Errorf("GEN: string() expects a quoted alphabet")
+		}
+		alphabet := expandAlphabet(lit.value)
+		if len(alphabet) == 0 {
+			return genValue{}, fmt.Errorf("GEN: string() alphabet is empty")
+		}
+		b := make([]rune, n)
+		for i := range b {
+			b[i] = alphabet[env.rng.IntN(len(alphabet))]
+		}
+		return genValue{lines: []string{string(b)}}, nil
+
+	case "choice":
+		return e.args[env.rng.IntN(len(e.args))].eval(env)
+	}
+	return genValue{}, fmt.Errorf("GEN: unknown function %s", e.name)
+}
+
+func evalInt(env *genEnv, e genExpr) (int64, error) {
+	v, err := e.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	if !v.isNum {
+		return 0, fmt.Errorf("GEN: expected a number, got %q", strings.Join(v.lines, " "))
+	}
+	return v.num, nil
+}
+
+func evalCount(env *genEnv, fn string, e genExpr) (int64, error) {
+	n, err := evalInt(env, e)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("GEN: %s() length %d is negative", fn, n)
+	}
+	if n > genMaxSize-env.size {
+		return 0, fmt.Errorf("GEN: %s() length %d exceeds the limit of %d generated values", fn, n, genMaxSize)
+	}
+	env.size += n
+	return n, nil
+}
+
+// expandAlphabet turns "a-z0-9_" into the characters it denotes.
+func expandAlphabet(spec string) []rune {
+	runes := []rune(spec)
+	var out []rune
+	for i := 0; i < len(runes); i++ {
+		if i+2 < len(runes) && runes[i+1] == '-' && runes[i] <= runes[i+2] {
+			for r := runes[i]; r <= runes[i+2]; r++ {
+				out = append(out, r)
+			}
+			i += 2
+			continue
+		}
+		out = append(out, runes[i])
+	}
+	return out
+}
+
+// genParser is a recursive descent parser over the GEN source.
+type genParser struct {
+	src     string
+	pos     int
+	defined map[string]bool
+}
+
+// parseGenSpec parses statements separated by ";":
+//
+//	stmt := [name "="] expr
+//	expr := term {("+" | "-") term}
+//	term := factor {("*" | "/" | "%") factor}
+//	factor := number | "string" | name | name "(" expr {"," expr} ")" | "(" expr ")" | "-" factor
+func parseGenSpec(src string) (*genSpec, error) {
+	p := &genParser{src: src, defined: make(map[string]bool)}
+	spec := &genSpec{}
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			break
+		}
+		if p.peek() == ';' {
+			p.pos++
+			continue
+		}
+
+		stmt, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		spec.stmts = append(spec.stmts, stmt)
+
+		p.skipSpace()
+		if p.pos < len(p.src) && p.peek() != ';' {
+			return nil, p.errorf("expected ;")
+		}
+	}
+
+	if len(spec.stmts) == 0 {
+		return nil, fmt.Errorf("GEN: empty generator")
+	}
+	return spec, nil
+}
+
+func (p *genParser) statement() (genStmt, error) {
+	start := p.pos
+	if name := p.ident(); name != "" {
+		p.skipSpace()
+		if p.peek() == '=' {
+			p.pos++
+			expr, err := p.expr()
+			if err != nil {
+				return genStmt{}, err
+			}
+			p.defined[name] = true
+			return genStmt{name: name, expr: expr}, nil
+		}
+	}
+
+	p.pos = start
+	expr, err := p.expr()
+	return genStmt{expr: expr}, err
+}
+
+func (p *genParser) expr() (genExpr, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = genBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *genParser) term() (genExpr, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = genBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *genParser) factor() (genExpr, error) {
+	p.skipSpace()
+	c := p.peek()
+	switch {
+	case c == '-':
+		p.pos++
+		operand, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return genBinary{op: '-', left: genNum{}, right: operand}, nil
+
+	case c == '(':
+		p.pos++
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(')') {
+			return nil, p.errorf("expected )")
+		}
+		return inner, nil
+
+	case c == '"':
+		end := strings.IndexByte(p.src[p.pos+1:], '"')
+		if end == -1 {
+			return nil, p.errorf("unterminated string")
+		}
+		value := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return genStr{value: value}, nil
+
+	case c >= '0' && c <= '9':
+		return p.number()
+	}
+
+	name := p.ident()
+	if name == "" {
+		return nil, p.errorf("unexpected %q", string(c))
+	}
+	if !p.consume('(') {
+		if !p.defined[name] {
+			return nil, p.errorf("undefined variable %s", name)
+		}
+		return genVar{name: name}, nil
+	}
+
+	arity, ok := genFuncArity[name]
+	if !ok {
+		return nil, p.errorf("unknown function %s", name)
+	}
+	var args []genExpr
+	for {
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.consume(')') {
+			break
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected , or )")
+		}
+	}
+	if arity >= 0 && len(args) != arity {
+		return nil, p.errorf("%s() takes %d arguments, got %d", name, arity, len(args))
+	}
+	return genCall{name: name, args: args}, nil
+}
+
+// number reads an integer, allowing an exponent such as 2e5.
+func (p *genParser) number() (genExpr, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (unicode.IsDigit(rune(p.src[p.pos])) || p.src[p.pos] == 'e') {
+		p.pos++
+	}
+	text := p.src[start:p.pos]
+
+	mantissa, exponent, hasExp := strings.Cut(text, "e")
+	n, err := strconv.ParseInt(mantissa, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %s", text)
+	}
+	if hasExp {
+		e, err := strconv.Atoi(exponent)
+		if err != nil || e > 18 {
+			return nil, p.errorf("invalid number %s", text)
+		}
+		for ; e > 0; e-- {
+			if n > math.MaxInt64/10 {
+				return nil, p.errorf("number %s is too large", text)
+			}
+			n *= 10
+		}
+	}
+	return genNum{value: n}, nil
+}
+
+// parseGenNumber reads an integer the way GEN literals are written, so
+// overrides accept values such as 2e5.
+func parseGenNumber(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	sign := int64(1)
+	if strings.HasPrefix(raw, "-") {
+		sign, raw = -1, raw[1:]
+	}
+	p := &genParser{src: raw}
+	if raw == "" || raw[0] < '0' || raw[0] > '9' {
+		return 0, fmt.Errorf("invalid number %q", raw)
+	}
+	e, err := p.number()
+	if err != nil {
+		return 0, err
+	}
+	if p.pos != len(raw) {
+		return 0, fmt.Errorf("invalid number %q", raw)
+	}
+	return sign * e.(genNum).value, nil
+}
+
+func (p *genParser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := rune(p.src[p.pos])
+		if !(c == '_' || unicode.IsLetter(c) || (p.pos > start && unicode.IsDigit(c))) {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *genParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *genParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *genParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *genParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("GEN: %s at column %d", fmt.Sprintf(format, args...), p.pos+1)
+}
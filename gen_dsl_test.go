@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGenSpecGenerate(t *testing.T) {
+	spec, err := parseGenSpec(`n=int(1,10); a=array(n,int(-100,100)); _m=int(2,3); lines(_m, array(2, int(1, n))); string(n, "a-c")`)
+	if err != nil {
+		t.Fatalf("parseGenSpec: %v", err)
+	}
+
+	for seed := uint64(1); seed <= 50; seed++ {
+		lines, err := spec.Generate(seed, nil)
+		if err != nil {
+			t.Fatalf("Generate(%d): %v", seed, err)
+		}
+		n, err := strconv.Atoi(lines[0])
+		if err != nil || n < 1 || n > 10 {
+			t.Fatalf("seed %d: n = %q out of range", seed, lines[0])
+		}
+		values := strings.Fields(lines[1])
+		if len(values) != n {
+			t.Fatalf("seed %d: array has %d values, want %d", seed, len(values), n)
+		}
+		for _, v := range values {
+			if x, _ := strconv.Atoi(v); x < -100 || x > 100 {
+				t.Fatalf("seed %d: value %s out of range", seed, v)
+			}
+		}
+		if m := len(lines) - 3; m < 2 || m > 3 {
+			t.Fatalf("seed %d: expected 2 or 3 pair lines, got %q", seed, lines)
+		}
+		if last := lines[len(lines)-1]; len(last) != n || strings.Trim(last, "abc") != "" {
+			t.Fatalf("seed %d: unexpected string %q", seed, last)
+		}
+	}
+
+	first, _ := spec.Generate(7, nil)
+	again, _ := spec.Generate(7, nil)
+	if !slices.Equal(first, again) {
+		t.Fatalf("the same seed must generate the same input")
+	}
+}
+
+func TestGenSpecOverridesAndPerm(t *testing.T) {
+	spec, err := parseGenSpec("n=int(1,5); perm(n)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := spec.Generate(3, map[string]int64{"n": 2e5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines[0] != "200000" || len(strings.Fields(lines[1])) != 200000 {
+		t.Fatalf("override was not applied: n=%s with %d values", lines[0], len(strings.Fields(lines[1])))
+	}
+}
+
+func TestGenSpecErrors(t *testing.T) {
+	for _, src := range []string{
+		"a=array(n, int(1,2))",
+		"x=int(1)",
+		"x=foo(1)",
+		"x=int(1,2",
+		"x=int(1,2) y",
+		"x=int(1,10e18)",
+		"x=int(1,1e30)",
+	} {
+		if _, err := parseGenSpec(src); err == nil {
+			t.Fatalf("parseGenSpec(%q) should fail", src)
+		}
+	}
+
+	for _, src := range []string{
+		"n=1e18; perm(n)",
+		"n=1e18; string(n, \"a-z\")",
+		"n=1e4; lines(n, array(n, 1))",
+		"x=9e18 + 9e18",
+		"x=0 - 9e18 - 9e18",
+		"x=5e9 * 5e9",
+		"x=0 - 9223372036854775807 - 1; y=x / (0 - 1)",
+	} {
+		spec, err := parseGenSpec(src)
+		if err != nil {
+			t.Fatalf("parseGenSpec(%q): %v", src, err)
+		}
+		if _, err := spec.Generate(1, nil); err == nil {
+			t.Fatalf("Generate(%q) should fail", src)
+		}
+	}
+}
+
+func TestGenCommandWritesExternalCases(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.cpp")
+	content := "/*defiprompt\nGEN: n=int(1,3)\nGEN: array(n, 7)\n*/\nint main() {}\n"
+	if err := os.WriteFile(source, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := runGenCommand([]string{source, "--count", "2", "--set", "n=4", "--external"}, &out); err != nil {
+		t.Fatalf("runGenCommand: %v", err)
+	}
+
+	cases, err := loadTestSuite(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 2 || !cases[1].Pending || !slices.Equal(cases[1].Inputs, []string{"4", "7 7 7 7"}) {
+		t.Fatalf("unexpected cases %+v", cases)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pedrohff/defi/components"
+)
+
+const (
+	// defaultHistoryDir holds the run history, relative to the working directory.
+	defaultHistoryDir = ".defi"
+	historyFileName   = "history.jsonl"
+	// snapshotDirName holds one copy of every recorded source, named after
+	// its SourceHash so unchanged sources are stored once.
+	snapshotDirName = "snapshots"
+)
+
+// A passing case counts as slower when it takes slowdownFactor times as long
+// as in the previous run, and at least slowdownMinimum longer, which keeps
+// the noise of very fast cases out.
+const (
+	slowdownFactor  = 2
+	slowdownMinimum = 50 * time.Millisecond
+)
+
+// historyRun is one line of the history file.
+type historyRun struct {
+	Time       time.Time     `json:"time"`
+	File       string        `json:"file"`
+	SourceHash string        `json:"source_hash"`
+	Passed     int           `json:"passed"`
+	Total      int           `json:"total"`
+	Err        string        `json:"error,omitempty"`
+	Cases      []historyCase `json:"cases,omitempty"`
+}
+
+// historyCase is the verdict of one case. Cases are matched across runs by
+// Key, a hash of their input, so adding or reordering cases is harmless.
+type historyCase struct {
+	Key     string     `json:"key"`
+	Verdict testStatus `json:"verdict"`
+	TimeMS  float64    `json:"time_ms,omitempty"`
+	Err     string     `json:"error,omitempty"`
+}
+
+// historyStore appends runs to a JSON Lines file inside dir.
+type historyStore struct {
+	dir string
+}
+
+// historyRecordedMsg reports that a run was saved to the history, along with
+// how many cases regressed since the previous run of the file.
+type historyRecordedMsg struct {
+	Regressions int
+	Err         error
+}
+
+// openHistory returns the store in dir, or nil when dir is empty, which
+// disables the history.
+func openHistory(dir string) *historyStore {
+	if dir == "" {
+		return nil
+	}
+	return &historyStore{dir: dir}
+}
+
+func (h *historyStore) path() string {
+	return filepath.Join(h.dir, historyFileName)
+}
+
+// Append adds run to the end of the history.
+func (h *historyStore) Append(run historyRun) error {
+	if err := os.MkdirAll(h.dir, 0o755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(h.path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SaveSnapshot stores content under its hash unless it is already there.
+func (h *historyStore) SaveSnapshot(hash string, content []byte) error {
+	dir := filepath.Join(h.dir, snapshotDirName)
+	path := filepath.Join(dir, hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary name first so a half-written snapshot is never
+	// mistaken for a complete one.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Snapshot returns the source recorded under hash.
+func (h *historyStore) Snapshot(hash string) ([]byte, error) {
+	if hash == "" {
+		return nil, errors.New("run has no source snapshot")
+	}
+	content, err := os.ReadFile(filepath.Join(h.dir, snapshotDirName, hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("snapshot %s is missing", shortHash(hash))
+	}
+	return content, err
+}
+
+// Files lists the recorded sources, most recently run first.
+func (h *historyStore) Files() ([]string, error) {
+	runs, err := h.Runs("")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for i := len(runs) - 1; i >= 0; i-- {
+		if !slices.Contains(files, runs[i].File) {
+			files = append(files, runs[i].File)
+		}
+	}
+	return files, nil
+}
+
+// Runs returns the recorded runs of file, or of every file when file is
+// empty, oldest first. Lines that cannot be decoded are skipped so a damaged
+// history does not get in the way.
+func (h *historyStore) Runs(file string) ([]historyRun, error) {
+	f, err := os.Open(h.path())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if file != "" {
+		file = historyKey(file)
+	}
+	var runs []historyRun
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var run historyRun
+		if json.Unmarshal(scanner.Bytes(), &run) != nil || (file != "" && run.File != file) {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, scanner.Err()
+}
+
+// Last returns the latest run of file that got as far as running cases.
+func (h *historyStore) Last(file string) (*historyRun, error) {
+	runs, err := h.Runs(file)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if len(runs[i].Cases) > 0 {
+			return &runs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// historyKey is how a source path is stored, so "./a.cpp" and "a.cpp" match.
+func historyKey(path string) string {
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// shortHash abbreviates a source hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// hashLines identifies a case by its input.
+func hashLines(lines []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// historyRecorder follows the messages of one run, marking regressions
+// against the previous run as cases finish and saving the run at the end.
+type historyRecorder struct {
+	store    *historyStore
+	run      historyRun
+	source   []byte
+	previous map[string]historyCase
+	regress  int
+}
+
+func newHistoryRecorder(store *historyStore, sourcePath string) *historyRecorder {
+	r := &historyRecorder{
+		store: store,
+		run:   historyRun{Time: time.Now(), File: historyKey(sourcePath)},
+	}
+	if content, err := os.ReadFile(sourcePath); err == nil {
+		sum := sha256.Sum256(content)
+		r.run.SourceHash = hex.EncodeToString(sum[:])
+		r.source = content
+	}
+	// Without a readable history every case is simply new.
+	if last, err := store.Last(sourcePath); err == nil && last != nil {
+		r.previous = make(map[string]historyCase, len(last.Cases))
+		for _, c := range last.Cases {
+			r.previous[c.Key] = c
+		}
+	}
+	return r
+}
+
+// observe records finished cases and flags the ones that regressed.
+func (r *historyRecorder) observe(msg tea.Msg) tea.Msg {
+	v, ok := msg.(testStatusMsg)
+	if !ok || v.Status == testStatusRunning {
+		return msg
+	}
+
+	c := historyCase{
+		Key:     hashLines(v.Inputs),
+		Verdict: v.Status,
+		TimeMS:  durationMillis(v.Duration),
+	}
+	if v.Err != nil {
+		c.Err = v.Err.Error()
+	}
+	r.run.Cases = append(r.run.Cases, c)
+
+	if prev, ok := r.previous[c.Key]; ok && prev.Verdict == testStatusPassed {
+		v.Regression = regressionOf(prev, c)
+	}
+	if v.Regression != "" {
+		r.regress++
+	}
+	return v
+}
+
+// regressionOf compares a case that passed before with its latest result.
+func regressionOf(prev, cur historyCase) string {
+	switch {
+	case cur.Verdict == testStatusFailed:
+		return components.TestCaseRegressionFailing
+	case cur.TimeMS > prev.TimeMS*slowdownFactor && cur.TimeMS-prev.TimeMS > durationMillis(slowdownMinimum):
+		return components.TestCaseRegressionSlower
+	}
+	return ""
+}
+
+// finish saves the run together with a snapshot of the source it tested.
+func (r *historyRecorder) finish(passed, total int, err error) historyRecordedMsg {
+	r.run.Passed, r.run.Total = passed, total
+	if err != nil {
+		r.run.Err = err.Error()
+	}
+	msg := historyRecordedMsg{Regressions: r.regress}
+	if r.source != nil {
+		if werr := r.store.SaveSnapshot(r.run.SourceHash, r.source); werr != nil {
+			msg.Err = fmt.Errorf("failed to save source snapshot: %w", werr)
+			r.run.SourceHash = ""
+		}
+	}
+	if werr := r.store.Append(r.run); werr != nil {
+		msg.Err = fmt.Errorf("failed to save history: %w", werr)
+	}
+	return msg
+}
+
+// passed reports whether every case of the run passed.
+func (r historyRun) passed() bool {
+	return r.Err == "" && r.Total > 0 && r.Passed == r.Total
+}
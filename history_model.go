@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pedrohff/defi/components"
+	"github.com/pedrohff/defi/view"
+)
+
+const historyUsageMessage = "usage: defi history [file] [--diff] [--dir .defi]"
+
+const (
+	historyHint     = "↑/↓ select run • pgup/pgdn scroll diff • q quit"
+	historyDiffRows = 10 // lines moved by pgup/pgdn
+	// diffContextLines is how much unchanged code surrounds each change.
+	diffContextLines = 3
+)
+
+// runHistoryCommand lists the recorded runs of a file and shows what changed
+// in its source since it last passed.
+func runHistoryCommand(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("defi history", flag.ContinueOnError)
+	diffFlag := fs.Bool("diff", false, "Print the diff between the last passing and the latest run, then exit")
+	dirFlag := fs.String("dir", defaultHistoryDir, "History directory")
+
+	// Accept flags both before and after the file.
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	file := fs.Arg(0)
+	if fs.NArg() > 0 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return usageError{err}
+		}
+		if fs.NArg() > 0 {
+			return usageErrorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+		}
+	}
+
+	store := openHistory(*dirFlag)
+	if file == "" {
+		files, err := store.Files()
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no runs recorded in %s", *dirFlag)
+		}
+		file = files[0]
+	}
+
+	runs, err := store.Runs(file)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		return fmt.Errorf("no runs recorded for %s", file)
+	}
+
+	if *diffFlag {
+		cmp, err := compareRuns(store, runs, len(runs)-1)
+		if err != nil {
+			return err
+		}
+		if cmp.base < 0 {
+			fmt.Fprintln(stdout, cmp.title)
+			return nil
+		}
+		from := fmt.Sprintf("%s@%s", file, shortHash(runs[cmp.base].SourceHash))
+		to := fmt.Sprintf("%s@%s", file, shortHash(runs[len(runs)-1].SourceHash))
+		writeUnifiedDiff(stdout, from, to, cmp.lines, diffContextLines)
+		return nil
+	}
+
+	theme, err := userTheme()
+	if err != nil {
+		return err
+	}
+	_, err = tea.NewProgram(newHistoryModel(store, file, runs, theme), tea.WithAltScreen()).Run()
+	return err
+}
+
+// runComparison is the diff shown for a run.
+type runComparison struct {
+	// base indexes the run compared against, -1 when there is none.
+	base  int
+	title string
+	lines []diffLine
+}
+
+// compareRuns diffs the source of runs[idx] against the last passing run
+// before it, or against the run right before it when runs[idx] passed too.
+func compareRuns(store *historyStore, runs []historyRun, idx int) (runComparison, error) {
+	base := -1
+	if runs[idx].passed() {
+		base = idx - 1
+	} else {
+		for i := idx - 1; i >= 0; i-- {
+			if runs[i].passed() {
+				base = i
+				break
+			}
+		}
+	}
+
+	switch {
+	case base < 0 && runs[idx].passed():
+		return runComparison{base: -1, title: "First recorded run"}, nil
+	case base < 0:
+		return runComparison{base: -1, title: "No passing run before this one"}, nil
+	case runs[base].SourceHash == runs[idx].SourceHash:
+		return runComparison{base: -1, title: fmt.Sprintf("Source unchanged since %s", runLabel(runs[base]))}, nil
+	}
+
+	from, err := store.Snapshot(runs[base].SourceHash)
+	if err != nil {
+		return runComparison{}, err
+	}
+	to, err := store.Snapshot(runs[idx].SourceHash)
+	if err != nil {
+		return runComparison{}, err
+	}
+
+	verb := "Since last pass"
+	if runs[idx].passed() {
+		verb = "Since previous run"
+	}
+	return runComparison{
+		base:  base,
+		title: fmt.Sprintf("%s %s", verb, shortHash(runs[base].SourceHash)),
+		lines: lineDiff(splitSource(from), splitSource(to)),
+	}, nil
+}
+
+// runLabel names a run by its time and source hash.
+func runLabel(run historyRun) string {
+	return fmt.Sprintf("%s %s", run.Time.Local().Format("Jan 2 15:04:05"), shortHash(run.SourceHash))
+}
+
+// historyModel is the TUI of `defi history`: runs newest first on the left
+// and the diff of the selected one in the details pane.
+type historyModel struct {
+	store *historyStore
+	file  string
+	runs  []historyRun // oldest first, as stored
+	theme components.Theme
+
+	selected   int // index into runs
+	listOffset int
+	diff       []string
+	diffTitle  string
+	diffOffset int
+	err        error
+
+	width  int
+	height int
+	ready  bool
+}
+
+func newHistoryModel(store *historyStore, file string, runs []historyRun, theme components.Theme) historyModel {
+	m := historyModel{store: store, file: file, runs: runs, theme: themeOrDefault(theme)}
+	m.selectRun(len(runs) - 1)
+	return m
+}
+
+func (m historyModel) Init() tea.Cmd {
+	return nil
+}
+
+// selectRun shows the diff of runs[idx].
+func (m *historyModel) selectRun(idx int) {
+	m.selected = idx
+	m.diffOffset = 0
+	m.diff, m.err = nil, nil
+
+	cmp, err := compareRuns(m.store, m.runs, idx)
+	if err != nil {
+		m.err = err
+		m.diffTitle = "Diff unavailable"
+		return
+	}
+	m.diffTitle = cmp.title
+	if cmp.base < 0 {
+		return
+	}
+
+	var buf bytes.Buffer
+	writeUnifiedDiff(&buf, "", "", cmp.lines, diffContextLines)
+	// Drop the ---/+++ header; the title names both versions.
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	m.diff = lines[2:]
+}
+
+func (m historyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.ready = true
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "up", "k":
+			if m.selected < len(m.runs)-1 {
+				m.selectRun(m.selected + 1)
+			}
+		case "down", "j":
+			if m.selected > 0 {
+				m.selectRun(m.selected - 1)
+			}
+		case "pgdown":
+			m.diffOffset = min(m.diffOffset+historyDiffRows, max(len(m.diff)-1, 0))
+		case "pgup":
+			m.diffOffset = max(m.diffOffset-historyDiffRows, 0)
+		}
+		// The list shows the newest run first.
+		row := len(m.runs) - 1 - m.selected
+		m.listOffset = view.ScrollOffset(m.listOffset, row, view.ListRows(view.LayoutSplitHorizontal, m.height, true, len(m.runs)), len(m.runs))
+	}
+	return m, nil
+}
+
+func (m historyModel) View() string {
+	if !m.ready {
+		return "🚀 Starting Défi...\n"
+	}
+
+	rows := make([]view.TestCaseData, 0, len(m.runs))
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		run := m.runs[i]
+		rows = append(rows, view.TestCaseData{
+			Name:             fmt.Sprintf("%s • %d/%d", runLabel(run), run.Passed, run.Total),
+			Status:           components.TestCaseFinished,
+			CompileSuccess:   len(run.Cases) > 0,
+			AssertionSuccess: run.passed(),
+		})
+	}
+
+	lines, title := m.diff, m.diffTitle
+	if m.err != nil {
+		lines = []string{m.err.Error()}
+	} else if len(lines) == 0 {
+		lines = []string{"Nothing to compare."}
+	}
+	offset := m.diffOffset
+
+	status := fmt.Sprintf("%d runs recorded", len(m.runs))
+	if run := m.runs[m.selected]; run.Err != "" {
+		status = shortenString(run.Err, 60)
+	}
+
+	return view.NewMainView(m.width, m.height, rows,
+		view.WithSelectedIndex(len(m.runs)-1-m.selected),
+		view.WithListOffset(m.listOffset),
+		view.WithPanel(func(width, height int) string {
+			return components.SourceDiff(m.theme, width, height-2, title, lines, offset, historyHint)
+		}),
+		view.WithFilename(footerFilename(m.file)),
+		view.WithLanguage(languageLabelForPath(m.file)),
+		view.WithStatus(status),
+		view.WithTheme(m.theme),
+	).Render()
+}
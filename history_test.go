@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrohff/defi/components"
+)
+
+func TestHistoryStoreKeepsRunsPerFile(t *testing.T) {
+	store := openHistory(filepath.Join(t.TempDir(), ".defi"))
+	runs := []historyRun{
+		{File: "a.cpp", Passed: 1, Total: 1, Cases: []historyCase{{Key: "k", Verdict: testStatusPassed}}},
+		{File: "b.cpp", Passed: 0, Total: 1, Cases: []historyCase{{Key: "k", Verdict: testStatusFailed}}},
+		{File: "a.cpp", Err: "compilation failed"},
+	}
+	for _, run := range runs {
+		if err := store.Append(run); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	got, err := store.Runs("./a.cpp")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("expected 2 runs of a.cpp, got %d, %v", len(got), err)
+	}
+	last, err := store.Last("a.cpp")
+	if err != nil || last == nil || last.Passed != 1 {
+		t.Fatalf("expected the last run with cases, got %+v, %v", last, err)
+	}
+	if last, err := store.Last("c.cpp"); err != nil || last != nil {
+		t.Fatalf("expected no run of c.cpp, got %+v, %v", last, err)
+	}
+}
+
+func TestHistoryRecorderFlagsRegressions(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.cpp")
+	if err := os.WriteFile(source, []byte("int main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := openHistory(filepath.Join(dir, ".defi"))
+
+	finished := func(inputs string, status testStatus, d time.Duration) testStatusMsg {
+		return testStatusMsg{Status: status, Inputs: []string{inputs}, Duration: d}
+	}
+
+	first := newHistoryRecorder(store, source)
+	for _, msg := range []testStatusMsg{
+		finished("1", testStatusPassed, 10*time.Millisecond),
+		finished("2", testStatusPassed, 10*time.Millisecond),
+		finished("3", testStatusPassed, 10*time.Millisecond),
+		finished("4", testStatusFailed, 0),
+	} {
+		if got := first.observe(msg).(testStatusMsg); got.Regression != "" {
+			t.Fatalf("first run flagged %q", got.Regression)
+		}
+	}
+	if msg := first.finish(3, 4, errors.New("case 4 failed")); msg.Err != nil || msg.Regressions != 0 {
+		t.Fatalf("unexpected %+v", msg)
+	}
+
+	second := newHistoryRecorder(store, source)
+	want := []struct {
+		msg        testStatusMsg
+		regression string
+	}{
+		{finished("1", testStatusFailed, 0), components.TestCaseRegressionFailing},
+		{finished("2", testStatusPassed, 200*time.Millisecond), components.TestCaseRegressionSlower},
+		{finished("3", testStatusPassed, 15*time.Millisecond), ""},
+		{finished("4", testStatusFailed, 0), ""},
+		{finished("5", testStatusFailed, 0), ""},
+	}
+	for _, w := range want {
+		if got := second.observe(w.msg).(testStatusMsg); got.Regression != w.regression {
+			t.Fatalf("input %s: expected %q, got %q", w.msg.Inputs[0], w.regression, got.Regression)
+		}
+	}
+	if msg := second.finish(2, 5, nil); msg.Regressions != 2 {
+		t.Fatalf("expected 2 regressions, got %+v", msg)
+	}
+
+	last, err := store.Last(source)
+	if err != nil || len(last.Cases) != 5 || last.SourceHash == "" {
+		t.Fatalf("unexpected last run %+v, %v", last, err)
+	}
+}
+
+func TestHistoryDiffShowsChangesSinceLastPass(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.cpp")
+	historyDir := filepath.Join(dir, ".defi")
+	store := openHistory(historyDir)
+
+	record := func(content string, passed int) {
+		t.Helper()
+		if err := os.WriteFile(source, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		r := newHistoryRecorder(store, source)
+		r.observe(testStatusMsg{Status: testStatusPassed, Inputs: []string{"1"}})
+		if msg := r.finish(passed, 1, nil); msg.Err != nil {
+			t.Fatal(msg.Err)
+		}
+	}
+	record("int a;\nint b;\n", 1)
+	record("int a;\nint c;\n", 0)
+	record("int a;\nint d;\n", 0)
+
+	var out bytes.Buffer
+	if err := runHistoryCommand([]string{source, "--diff", "--dir", historyDir}, &out); err != nil {
+		t.Fatalf("runHistoryCommand: %v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "-int b;\n+int d;\n") || strings.Contains(got, "int c;") {
+		t.Fatalf("expected the diff against the passing version, got:\n%s", got)
+	}
+
+	// Without a file, the most recently run one is used.
+	out.Reset()
+	record("int a;\nint b;\n", 1)
+	if err := runHistoryCommand([]string{"--diff", "--dir", historyDir}, &out); err != nil {
+		t.Fatalf("runHistoryCommand: %v", err)
+	}
+	if !strings.Contains(out.String(), "-int d;\n+int b;\n") {
+		t.Fatalf("expected the diff against the previous run, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// alwaysIgnored lists directories that never hold solutions worth watching.
+var alwaysIgnored = []string{".git/", ".defi/"}
+
+// ignoreRule is a single gitignore-style pattern.
+type ignoreRule struct {
+	// base is the slash-separated directory, relative to the watch root, of
+	// the .gitignore declaring the rule; empty for root level rules.
+	base     string
+	re       *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	basename bool
+}
+
+// ignoreMatcher evaluates gitignore-style rules; the last matching rule wins.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// newIgnoreMatcher builds a matcher from root level patterns.
+func newIgnoreMatcher(patterns []string) *ignoreMatcher {
+	m := &ignoreMatcher{}
+	for _, p := range alwaysIgnored {
+		m.add("", p)
+	}
+	for _, p := range patterns {
+		m.add("", p)
+	}
+	return m
+}
+
+// add compiles pattern relative to base. Blank lines and comments are skipped.
+func (m *ignoreMatcher) add(base, pattern string) {
+	pattern = strings.TrimRight(pattern, " \t\r")
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	if strings.HasPrefix(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else if !strings.Contains(pattern, "/") {
+		rule.basename = true
+	}
+	if pattern == "" {
+		return
+	}
+
+	re, err := regexp.Compile("^" + globToRegexp(pattern) + "$")
+	if err != nil {
+		return
+	}
+	rule.re = re
+	m.rules = append(m.rules, rule)
+}
+
+// addGitignore loads the .gitignore file of dir, given relative to root.
+func (m *ignoreMatcher) addGitignore(root, dir string) error {
+	f, err := os.Open(filepath.Join(root, dir, ".gitignore"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	base := filepath.ToSlash(dir)
+	if base == "." {
+		base = ""
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.add(base, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// Ignored reports whether rel, a slash-separated path relative to the watch
+// root, is excluded either directly or through one of its parent directories.
+func (m *ignoreMatcher) Ignored(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(rel, isDir)
+}
+
+func (m *ignoreMatcher) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		target := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			target = strings.TrimPrefix(rel, rule.base+"/")
+		}
+		if rule.basename {
+			target = path.Base(target)
+		}
+
+		if rule.re.MatchString(target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp translates gitignore wildcards, including "**", to a regexp.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end == -1 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
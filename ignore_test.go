@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestIgnoreMatcher(t *testing.T) {
+	m := newIgnoreMatcher([]string{"*.log", "/tmp/", "docs/**/draft.cpp", "!keep.log"})
+	m.add("nested", "local.cpp")
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"a.log", false, true},
+		{"deep/b.log", false, true},
+		{"keep.log", false, false},
+		{"tmp", true, true},
+		{"tmp/a.cpp", false, true},
+		{"src/tmp", true, false},
+		{"docs/draft.cpp", false, true},
+		{"docs/x/y/draft.cpp", false, true},
+		{"nested/local.cpp", false, true},
+		{"local.cpp", false, false},
+		{".git/config", false, true},
+		{"a.cpp", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Ignored(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
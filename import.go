@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const importUsageMessage = "usage: defi import [--site name] [--external] <page.html|url> <source>"
+
+// importTimeout bounds how long fetching a remote problem page may take.
+const importTimeout = 15 * time.Second
+
+// runImportCommand extracts sample tests from a saved page or URL and stores
+// them next to the target source.
+func runImportCommand(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("defi import", flag.ContinueOnError)
+	siteFlag := fs.String("site", "", "Force a site parser (codeforces, atcoder, leetcode, generic)")
+	externalFlag := fs.Bool("external", false, "Write samples as external test files instead of a defiprompt block")
+
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() != 2 {
+		return usageErrorf("expected a page and a target source")
+	}
+	pageRef, sourcePath := fs.Arg(0), fs.Arg(1)
+
+	page, err := readProblemPage(pageRef)
+	if err != nil {
+		return err
+	}
+
+	extractor, err := findSampleExtractor(*siteFlag, pageRef, page)
+	if err != nil {
+		return err
+	}
+	cases, err := extractor.Extract(page)
+	if err != nil {
+		return fmt.Errorf("%s: %w", extractor.Name(), err)
+	}
+
+	if *externalFlag {
+		for _, c := range cases {
+			if _, err := addExternalCase(sourcePath, c.Inputs, c.Outputs); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(stdout, "Imported %d sample(s) from %s into %s\n", len(cases), extractor.Name(), externalTestsDir(sourcePath))
+		return nil
+	}
+
+	err = updateSourceFile(sourcePath, func(content string) (string, error) {
+		for _, c := range cases {
+			var err error
+			if content, err = appendPromptCase(content, c.Inputs, c.Outputs); err != nil {
+				return "", err
+			}
+		}
+		return content, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Imported %d sample(s) from %s into %s\n", len(cases), extractor.Name(), sourcePath)
+	return nil
+}
+
+// readProblemPage loads an HTML page from disk or over HTTP(S).
+func readProblemPage(ref string) (string, error) {
+	if !strings.HasPrefix(ref, "http://") && !strings.HasPrefix(ref, "https://") {
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %q: %w", ref, err)
+		}
+		return string(data), nil
+	}
+
+	client := &http.Client{Timeout: importTimeout}
+	resp, err := client.Get(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %q: %w", ref, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %q: %s", ref, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q: %w", ref, err)
+	}
+	return string(data), nil
+}
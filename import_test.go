@@ -0,0 +1,108 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const codeforcesPage = `<html><body>
+<div class="sample-tests"><div class="sample-test">
+<div class="input"><div class="title">Input</div><pre>
+<div class="test-example-line test-example-line-even">3</div><div class="test-example-line test-example-line-even">1 2 3</div>
+</pre></div>
+<div class="output"><div class="title">Output</div><pre>
+6
+</pre></div>
+<div class="input"><div class="title">Input</div><pre>1<br/>-5</pre></div>
+<div class="output"><div class="title">Output</div><pre>-5</pre></div>
+</div></div>
+</body></html>`
+
+const atcoderPage = `<span class="lang-ja">
+<h3>入力例 1</h3><pre>2 3
+</pre>
+<h3>出力例 1</h3><pre>5
+</pre>
+</span>
+<span class="lang-en">
+<h3>Sample Input 1</h3><pre>2 3
+</pre>
+<h3>Sample Output 1</h3><pre>5
+</pre>
+<h3>Sample Input 2</h3><pre>10 &amp; 20
+</pre>
+<h3>Sample Output 2</h3><pre>30
+</pre>
+</span>`
+
+func TestImportFromURLIntoSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, codeforcesPage)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.cpp")
+	if err := os.WriteFile(source, []byte("int main() {}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+
+	if err := runImportCommand([]string{"--site", "codeforces", server.URL + "/problem/1/A", source}, io.Discard); err != nil {
+		t.Fatalf("import returned error: %v", err)
+	}
+
+	cases, err := NewPromptParser(source).Parse()
+	if err != nil {
+		t.Fatalf("imported source does not parse: %v", err)
+	}
+	want := []PromptCase{
+		{Inputs: []string{"3", "1 2 3"}, Outputs: []string{"6"}},
+		{Inputs: []string{"1", "-5"}, Outputs: []string{"-5"}},
+	}
+	if !reflect.DeepEqual(cases, want) {
+		t.Fatalf("unexpected cases: %#v", cases)
+	}
+}
+
+func TestImportFromSavedPageIntoExternalTests(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "abc.html")
+	if err := os.WriteFile(page, []byte(atcoderPage), 0o644); err != nil {
+		t.Fatalf("failed to write page: %v", err)
+	}
+	source := filepath.Join(dir, "b.cpp")
+	if err := os.WriteFile(source, []byte("int main() {}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+
+	if err := runImportCommand([]string{"--external", page, source}, io.Discard); err != nil {
+		t.Fatalf("import returned error: %v", err)
+	}
+
+	cases, err := loadTestSuite(source)
+	if err != nil {
+		t.Fatalf("loadTestSuite returned error: %v", err)
+	}
+	if len(cases) != 2 {
+		t.Fatalf("expected 2 cases, got %d", len(cases))
+	}
+	if !reflect.DeepEqual(cases[1].Inputs, []string{"10 & 20"}) || !reflect.DeepEqual(cases[1].Outputs, []string{"30"}) {
+		t.Fatalf("unexpected second case: %#v", cases[1])
+	}
+	if cases[1].External != filepath.Join(dir, "b.tests", "2.in") {
+		t.Fatalf("unexpected external path %q", cases[1].External)
+	}
+}
+
+func TestLeetcodeValues(t *testing.T) {
+	got := leetcodeValues(`nums = [2,7,11,15], target = 9, s = "a,b"`)
+	want := []string{"[2,7,11,15]", "9", `"a,b"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected values: %#v", got)
+	}
+}
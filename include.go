@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// localIncludeRe matches quoted includes, which refer to project headers
+// rather than system ones.
+var localIncludeRe = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*include[ \t]*"([^"]+)"`)
+
+// includeEntry caches the includes of a file for a given modification time.
+type includeEntry struct {
+	modTime  time.Time
+	includes []string
+}
+
+// includeCache resolves the local headers a source depends on, re-reading a
+// file only when its modification time changes.
+type includeCache struct {
+	entries map[string]includeEntry
+}
+
+func newIncludeCache() *includeCache {
+	return &includeCache{entries: make(map[string]includeEntry)}
+}
+
+// dependencies returns the existing local headers transitively included by
+// path, together with the newest modification time among path and them.
+func (c *includeCache) dependencies(path string, modTime time.Time) ([]string, time.Time) {
+	var (
+		deps   []string
+		newest = modTime
+		seen   = map[string]bool{path: true}
+		queue  = c.includes(path, modTime)
+	)
+
+	for len(queue) > 0 {
+		dep := queue[0]
+		queue = queue[1:]
+		if seen[dep] {
+			continue
+		}
+		seen[dep] = true
+
+		info, err := os.Stat(dep)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		deps = append(deps, dep)
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		queue = append(queue, c.includes(dep, info.ModTime())...)
+	}
+
+	return deps, newest
+}
+
+// includes lists the quoted includes of path, resolved against its directory.
+func (c *includeCache) includes(path string, modTime time.Time) []string {
+	if entry, ok := c.entries[path]; ok && entry.modTime.Equal(modTime) {
+		return entry.includes
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var includes []string
+	for _, m := range localIncludeRe.FindAllStringSubmatch(string(data), -1) {
+		includes = append(includes, filepath.Clean(filepath.Join(filepath.Dir(path), m[1])))
+	}
+	c.entries[path] = includeEntry{modTime: modTime, includes: includes}
+	return includes
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap holds the key bindings of the main view. Each binding has a name,
+// used to rebind it in the config file.
+type keyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	Deselect key.Binding
+
+	SwitchFocus  key.Binding
+	ScrollDown   key.Binding
+	ScrollUp     key.Binding
+	HalfPageDown key.Binding
+	HalfPageUp   key.Binding
+
+	Rerun       key.Binding
+	RunCase     key.Binding
+	RerunFailed key.Binding
+	Record      key.Binding
+	Accept      key.Binding
+	Shrink      key.Binding
+	NewCase     key.Binding
+
+	PrevFile key.Binding
+	NextFile key.Binding
+	Layout   key.Binding
+	Maximize key.Binding
+	Help     key.Binding
+	Quit     key.Binding
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Up:       key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:     key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		PageUp:   key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up")),
+		PageDown: key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdn", "page down")),
+		Top:      key.NewBinding(key.WithKeys("home"), key.WithHelp("home", "first case")),
+		Bottom:   key.NewBinding(key.WithKeys("end"), key.WithHelp("end", "last case")),
+		Deselect: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "deselect")),
+
+		SwitchFocus:  key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "inputs/outputs")),
+		ScrollDown:   key.NewBinding(key.WithKeys("J"), key.WithHelp("J", "scroll down")),
+		ScrollUp:     key.NewBinding(key.WithKeys("K"), key.WithHelp("K", "scroll up")),
+		HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "scroll 10 down")),
+		HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u"), key.WithHelp("ctrl+u", "scroll 10 up")),
+
+		Rerun:       key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "re-run all")),
+		RunCase:     key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "run case")),
+		RerunFailed: key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "re-run failed")),
+		Record:      key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "record outputs")),
+		Accept:      key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "accept output")),
+		Shrink:      key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "shrink case")),
+		NewCase:     key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "new case")),
+
+		PrevFile: key.NewBinding(key.WithKeys("["), key.WithHelp("[", "previous file")),
+		NextFile: key.NewBinding(key.WithKeys("]"), key.WithHelp("]", "next file")),
+		Layout:   key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "layout")),
+		Maximize: key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "maximize")),
+		Help:     key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+		Quit:     key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+	}
+}
+
+// named returns the bindings by the name they have in the config file.
+func (k *keyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":             &k.Up,
+		"down":           &k.Down,
+		"page_up":        &k.PageUp,
+		"page_down":      &k.PageDown,
+		"top":            &k.Top,
+		"bottom":         &k.Bottom,
+		"deselect":       &k.Deselect,
+		"switch_focus":   &k.SwitchFocus,
+		"scroll_down":    &k.ScrollDown,
+		"scroll_up":      &k.ScrollUp,
+		"half_page_down": &k.HalfPageDown,
+		"half_page_up":   &k.HalfPageUp,
+		"rerun":          &k.Rerun,
+		"run_case":       &k.RunCase,
+		"rerun_failed":   &k.RerunFailed,
+		"record":         &k.Record,
+		"accept":         &k.Accept,
+		"shrink":         &k.Shrink,
+		"new_case":       &k.NewCase,
+		"prev_file":      &k.PrevFile,
+		"next_file":      &k.NextFile,
+		"layout":         &k.Layout,
+		"maximize":       &k.Maximize,
+		"help":           &k.Help,
+		"quit":           &k.Quit,
+	}
+}
+
+// newKeyMap returns the default bindings with the ones named in overrides
+// replaced, as read from the "keys" entry of the config file.
+func newKeyMap(overrides map[string][]string) (keyMap, error) {
+	k := defaultKeyMap()
+	named := k.named()
+	for name, keys := range overrides {
+		b, ok := named[name]
+		if !ok {
+			return k, fmt.Errorf("unknown key binding %q (want one of %s)", name, keyNames(named))
+		}
+		if len(keys) == 0 {
+			return k, fmt.Errorf("key binding %q has no keys", name)
+		}
+		b.SetKeys(keys...)
+		b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+	}
+	return k, nil
+}
+
+func keyNames(named map[string]*key.Binding) string {
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ShortHelp is shown in the help bar above the footer. Help comes first so it
+// survives truncation on narrow terminals.
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Help, k.Up, k.Down, k.Rerun, k.RerunFailed, k.Accept, k.NewCase, k.Layout, k.Maximize, k.Quit}
+}
+
+// FullHelp is shown in the ? overlay, one column per group.
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.Top, k.Bottom, k.Deselect},
+		{k.SwitchFocus, k.ScrollDown, k.ScrollUp, k.HalfPageDown, k.HalfPageUp},
+		{k.Rerun, k.RunCase, k.RerunFailed, k.Record, k.Accept, k.Shrink, k.NewCase},
+		{k.PrevFile, k.NextFile, k.Layout, k.Maximize, k.Help, k.Quit},
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNewKeyMapAppliesOverrides(t *testing.T) {
+	keys, err := newKeyMap(map[string][]string{"accept": {"A", "enter"}})
+	if err != nil {
+		t.Fatalf("newKeyMap: %v", err)
+	}
+	if got := keys.Accept.Keys(); len(got) != 2 || got[0] != "A" {
+		t.Fatalf("expected accept rebound, got %v", got)
+	}
+	if got := keys.Accept.Help().Key; got != "A/enter" {
+		t.Fatalf("expected the help to show the new keys, got %q", got)
+	}
+
+	if _, err := newKeyMap(map[string][]string{"acept": {"A"}}); err == nil || !strings.Contains(err.Error(), "accept") {
+		t.Fatalf("expected an unknown binding error listing the names, got %v", err)
+	}
+	if _, err := newKeyMap(map[string][]string{"accept": {}}); err == nil {
+		t.Fatalf("expected an error for a binding without keys")
+	}
+}
+
+func TestHelpOverlayAndReboundKeys(t *testing.T) {
+	m := newModel(appConfig{keys: map[string][]string{"down": {"x"}}}, "")
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(model)
+	m.resetForNewRun("a.cpp")
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 3}})
+	m = updated.(model)
+
+	if out := m.View(); !strings.Contains(out, "? help") || !strings.Contains(out, "x down") {
+		t.Fatalf("expected the help bar with the rebound key:\n%s", out)
+	}
+
+	updated, _ = m.Update(keyMsg("j"))
+	m = updated.(model)
+	updated, _ = m.Update(keyMsg("x"))
+	m = updated.(model)
+	if m.selectedIndex != 0 {
+		t.Fatalf("expected only the rebound key to move down, got selection %d", m.selectedIndex)
+	}
+
+	updated, _ = m.Update(keyMsg("?"))
+	m = updated.(model)
+	out := m.View()
+	if !m.showHelp || !strings.Contains(out, "previous file") || strings.Contains(out, "TEST CASE") {
+		t.Fatalf("expected the full help in place of the list:\n%s", out)
+	}
+	updated, _ = m.Update(keyMsg("x"))
+	if m = updated.(model); m.selectedIndex != 0 {
+		t.Fatalf("expected keys to be ignored while the help is open")
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m = updated.(model); m.showHelp || m.selectedIndex != 0 {
+		t.Fatalf("expected esc to close only the help")
+	}
+}
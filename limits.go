@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PromptLimits holds the resource limits declared at the top of a defiprompt
+// block with "TIME LIMIT: 2s" and "MEMORY LIMIT: 256MB" lines.
+type PromptLimits struct {
+	Time     time.Duration
+	MemoryMB int
+}
+
+const (
+	timeLimitDirective   = "TIME LIMIT:"
+	memoryLimitDirective = "MEMORY LIMIT:"
+)
+
+// withCompileFlags drops the memory limit for AddressSanitizer builds, which
+// reserve terabytes of address space at startup and could not run under a
+// cap on it.
+func (l PromptLimits) withCompileFlags(flags []string) PromptLimits {
+	for _, flag := range flags {
+		sanitizers, ok := strings.CutPrefix(flag, "-fsanitize=")
+		if !ok {
+			continue
+		}
+		for _, sanitizer := range strings.Split(sanitizers, ",") {
+			if sanitizer == "address" || sanitizer == "hwaddress" {
+				l.MemoryMB = 0
+			}
+		}
+	}
+	return l
+}
+
+// loadPromptLimits reads the limits declared in the defiprompt blocks of a source.
+func loadPromptLimits(sourcePath string) (PromptLimits, error) {
+	data, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return PromptLimits{}, fmt.Errorf("failed to read %q: %w", sourcePath, err)
+	}
+	return parsePromptLimits(string(data))
+}
+
+// promptHeaderLines returns the trimmed, non-blank lines preceding the first
+// case of every defiprompt block, where directives are declared.
+func promptHeaderLines(content string) ([]string, error) {
+	var lines []string
+
+	for searchAt := 0; ; {
+		start := strings.Index(content[searchAt:], promptMarker)
+		if start == -1 {
+			break
+		}
+		start += searchAt + len(promptMarker)
+
+		end := strings.Index(content[start:], "*/")
+		if end == -1 {
+			return lines, fmt.Errorf("unterminated defiprompt block")
+		}
+		end += start
+
+		for _, line := range strings.Split(content[start:end], "\n") {
+			line = strings.TrimSpace(line)
+			if line == "INPUTS" || line == "INPUTS:" {
+				break
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		searchAt = end + len("*/")
+	}
+
+	return lines, nil
+}
+
+// parsePromptLimits reads the limit directives of every defiprompt block.
+// Later declarations win.
+func parsePromptLimits(content string) (PromptLimits, error) {
+	var limits PromptLimits
+
+	lines, err := promptHeaderLines(content)
+	if err != nil {
+		return limits, err
+	}
+
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, timeLimitDirective):
+			d, err := parseTimeLimit(strings.TrimSpace(strings.TrimPrefix(line, timeLimitDirective)))
+			if err != nil {
+				return limits, err
+			}
+			limits.Time = d
+		case strings.HasPrefix(line, memoryLimitDirective):
+			mb, err := parseMemoryLimit(strings.TrimSpace(strings.TrimPrefix(line, memoryLimitDirective)))
+			if err != nil {
+				return limits, err
+			}
+			limits.MemoryMB = mb
+		}
+	}
+
+	return limits, nil
+}
+
+// parseTimeLimit accepts Go durations ("1500ms", "2s") or a bare number of seconds.
+func parseTimeLimit(value string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		if secs <= 0 {
+			return 0, fmt.Errorf("invalid time limit %q", value)
+		}
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid time limit %q", value)
+	}
+	return d, nil
+}
+
+// parseMemoryLimit accepts megabytes with an optional "MB" or "GB" suffix.
+func parseMemoryLimit(value string) (int, error) {
+	upper := strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+	scale := 1
+	switch {
+	case strings.HasSuffix(upper, "GB"):
+		scale = 1024
+		upper = strings.TrimSuffix(upper, "GB")
+	case strings.HasSuffix(upper, "MB"):
+		upper = strings.TrimSuffix(upper, "MB")
+	}
+
+	mb, err := strconv.Atoi(upper)
+	if err != nil || mb <= 0 {
+		return 0, fmt.Errorf("invalid memory limit %q", value)
+	}
+	return mb * scale, nil
+}
+
+// formatTimeLimit renders a limit the way it is written in defiprompt blocks.
+func formatTimeLimit(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// formatMemoryLimit renders a limit the way it is written in defiprompt blocks.
+func formatMemoryLimit(mb int) string {
+	if mb <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dMB", mb)
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+)
+
+// memoryLimitedCommand runs path with its address space capped at limitMB.
+// The shell sets the limit and then execs the program, so the cap is in
+// place before the program's first allocation.
+func memoryLimitedCommand(ctx context.Context, path string, args []string, limitMB int) *exec.Cmd {
+	script := `ulimit -v "$1" && shift && exec "$@"`
+	shellArgs := append([]string{"-c", script, "defi", strconv.Itoa(limitMB << 10), path}, args...)
+	return exec.CommandContext(ctx, "/bin/sh", shellArgs...)
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestMemoryLimitIsSetBeforeTheProgramStarts(t *testing.T) {
+	path := writeScript(t, t.TempDir(), "limit.sh", `echo "$@"; ulimit -v`)
+	outputs, err := execProgram(context.Background(), path, []string{"a", "b c"}, nil, PromptLimits{MemoryMB: 64})
+	if err != nil {
+		t.Fatalf("execProgram: %v", err)
+	}
+	if !slices.Equal(outputs, []string{"a b c", "65536"}) {
+		t.Fatalf("expected the arguments and a 64MB limit, got %q", outputs)
+	}
+}
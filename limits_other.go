@@ -0,0 +1,14 @@
+//go:build !linux
+
+package main
+
+import (
+	"context"
+	"os/exec"
+)
+
+// memoryLimitedCommand runs path without a memory limit, which cannot be
+// enforced for a child process here.
+func memoryLimitedCommand(ctx context.Context, path string, args []string, limitMB int) *exec.Cmd {
+	return exec.CommandContext(ctx, path, args...)
+}
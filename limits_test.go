@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddressSanitizerBuildsRunWithoutMemoryLimit(t *testing.T) {
+	limits := PromptLimits{Time: time.Second, MemoryMB: 256}
+	tests := []struct {
+		flags []string
+		want  int
+	}{
+		{nil, 256},
+		{[]string{"-std=c++17", "-O2"}, 256},
+		{[]string{"-fsanitize=undefined"}, 256},
+		{[]string{"-std=c++17", "-g", "-fsanitize=address,undefined"}, 0},
+		{[]string{"-fsanitize=undefined,address"}, 0},
+	}
+	for _, tt := range tests {
+		got := limits.withCompileFlags(tt.flags)
+		if got.MemoryMB != tt.want || got.Time != time.Second {
+			t.Fatalf("withCompileFlags(%q) = %+v, want %dMB", tt.flags, got, tt.want)
+		}
+	}
+}
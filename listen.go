@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const listenUsageMessage = "usage: defi listen [--port N] [--dir path] [--lang ext]"
+
+// defaultListenPort is one of the ports Competitive Companion posts to.
+const defaultListenPort = 10043
+
+// companionProblem is the payload sent by the Competitive Companion extension.
+type companionProblem struct {
+	Name        string          `json:"name"`
+	Group       string          `json:"group"`
+	URL         string          `json:"url"`
+	MemoryLimit int             `json:"memoryLimit"` // megabytes
+	TimeLimit   int             `json:"timeLimit"`   // milliseconds
+	Tests       []companionTest `json:"tests"`
+}
+
+type companionTest struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// problemReceivedMsg tells the TUI a new solution file was created.
+type problemReceivedMsg struct {
+	Name string
+	Path string
+	Err  error
+}
+
+// runListenCommand receives problems from Competitive Companion, scaffolds a
+// solution for each and watches the output directory in the TUI.
+func runListenCommand(args []string) error {
+	fs := flag.NewFlagSet("defi listen", flag.ContinueOnError)
+	portFlag := fs.Int("port", defaultListenPort, "Port Competitive Companion posts problems to")
+	dirFlag := fs.String("dir", ".", "Directory where solution files are created")
+	langFlag := fs.String("lang", "cpp", "Solution language, as a file extension")
+
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+
+	ext := "." + strings.TrimPrefix(*langFlag, ".")
+	if _, ok := supportedLanguages[ext]; !ok {
+		return usageErrorf("unsupported language %q", *langFlag)
+	}
+
+	spec, err := parseWatchSpec(*dirFlag)
+	if err != nil {
+		return err
+	}
+	cfg := appConfig{spec: spec, interval: time.Second, debounce: defaultDebounce, historyDir: defaultHistoryDir}
+	if err := applyUserSettings(&cfg, "", ""); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", *portFlag))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", *portFlag, err)
+	}
+
+	var program *tea.Program
+	server := &http.Server{
+		Handler: companionHandler(*dirFlag, ext, func(msg problemReceivedMsg) {
+			program.Send(msg)
+		}),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	defer server.Close()
+
+	m := newModel(cfg, "")
+	// Only problems received from now on should run.
+	m.ignoreInitialWatcher = true
+	m.footerStatus = fmt.Sprintf("Listening for problems on port %d...", *portFlag)
+	return runUI(m, func(p *tea.Program) {
+		program = p
+		go server.Serve(listener)
+	})
+}
+
+// companionHandler decodes problem payloads and creates their solution files.
+func companionHandler(dir, ext string, notify func(problemReceivedMsg)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var problem companionProblem
+		if err := json.NewDecoder(r.Body).Decode(&problem); err != nil {
+			http.Error(w, "invalid problem payload", http.StatusBadRequest)
+			notify(problemReceivedMsg{Err: fmt.Errorf("invalid problem payload: %w", err)})
+			return
+		}
+
+		path, err := scaffoldCompanionProblem(dir, ext, problem)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		notify(problemReceivedMsg{Name: problem.Name, Path: path, Err: err})
+	})
+}
+
+// scaffoldCompanionProblem renders a solution template with the problem's
+// tests and limits embedded in a defiprompt block.
+func scaffoldCompanionProblem(dir, ext string, problem companionProblem) (string, error) {
+	limits := PromptLimits{
+		Time:     time.Duration(problem.TimeLimit) * time.Millisecond,
+		MemoryMB: problem.MemoryLimit,
+	}
+
+	var cases []PromptCase
+	for _, t := range problem.Tests {
+		c := PromptCase{Inputs: formLines(t.Input), Outputs: formLines(t.Output)}
+		if len(c.Inputs) == 0 {
+			continue
+		}
+		cases = append(cases, c)
+	}
+
+	data := newSolutionData(problem.Name, limits, cases)
+	data.Group = problem.Group
+	data.URL = problem.URL
+
+	content, err := renderSolutionTemplate(ext, "", data)
+	if err != nil {
+		return "", err
+	}
+	return createSolutionFile(dir, solutionFileName(problem.Name, ext), content)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompanionHandlerScaffoldsSolution(t *testing.T) {
+	payload := `{
+		"name": "A. Watermelon",
+		"group": "Codeforces - Round 4",
+		"url": "https://codeforces.com/problemset/problem/4/A",
+		"memoryLimit": 64,
+		"timeLimit": 1000,
+		"tests": [{"input": "8\n", "output": "YES\n"}, {"input": "5\n", "output": "NO\n"}]
+	}`
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	var received problemReceivedMsg
+	handler := companionHandler(dir, ".cpp", func(msg problemReceivedMsg) {
+		received = msg
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	if received.Err != nil {
+		t.Fatalf("handler reported error: %v", received.Err)
+	}
+	if want := filepath.Join(dir, "a_watermelon.cpp"); received.Path != want {
+		t.Fatalf("expected %q, got %q", want, received.Path)
+	}
+
+	cases, err := loadTestSuite(received.Path)
+	if err != nil {
+		t.Fatalf("scaffolded solution does not parse: %v", err)
+	}
+	if len(cases) != 2 || cases[1].Outputs[0] != "NO" {
+		t.Fatalf("unexpected cases: %#v", cases)
+	}
+
+	limits, err := loadPromptLimits(received.Path)
+	if err != nil {
+		t.Fatalf("loadPromptLimits returned error: %v", err)
+	}
+	if limits != (PromptLimits{Time: time.Second, MemoryMB: 64}) {
+		t.Fatalf("unexpected limits: %+v", limits)
+	}
+
+	data, err := os.ReadFile(received.Path)
+	if err != nil {
+		t.Fatalf("failed to read solution: %v", err)
+	}
+	if !strings.Contains(string(data), "// https://codeforces.com/problemset/problem/4/A") {
+		t.Fatalf("solution is missing the problem URL:\n%s", data)
+	}
+}
+
+func TestListenChecksArgumentsBeforeBindingThePort(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	port := probe.Addr().(*net.TCPAddr).Port
+	probe.Close()
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	if err := runListenCommand([]string{"--port", strconv.Itoa(port), "--dir", missing}); err == nil {
+		t.Fatal("expected a missing directory to be rejected")
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatalf("expected the port to stay free: %v", err)
+	}
+	listener.Close()
+}
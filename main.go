@@ -1,13 +1,76 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// subcommand is a named defi command dispatched from the first argument.
+type subcommand struct {
+	usage string
+	run   func(args []string) error
+}
+
+var subcommands = map[string]subcommand{
+	"bench": {
+		usage: benchUsageMessage,
+		run: func(args []string) error {
+			return runBenchCommand(args, os.Stdout)
+		},
+	},
+	"gen": {
+		usage: genUsageMessage,
+		run: func(args []string) error {
+			return runGenCommand(args, os.Stdout)
+		},
+	},
+	"history": {
+		usage: historyUsageMessage,
+		run: func(args []string) error {
+			return runHistoryCommand(args, os.Stdout)
+		},
+	},
+	"import": {
+		usage: importUsageMessage,
+		run: func(args []string) error {
+			return runImportCommand(args, os.Stdout)
+		},
+	},
+	"listen": {
+		usage: listenUsageMessage,
+		run:   runListenCommand,
+	},
+	"new": {
+		usage: newUsageMessage,
+		run:   runNewCommand,
+	},
+	"shrink": {
+		usage: shrinkUsageMessage,
+		run:   runShrinkCommand,
+	},
+	"stress": {
+		usage: stressUsageMessage,
+		run:   runStressCommand,
+	},
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			err := cmd.run(os.Args[2:])
+			var usage usageError
+			if errors.As(err, &usage) {
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, cmd.usage)
+				os.Exit(1)
+			}
+			os.Exit(exitCode(err))
+		}
+	}
+
 	cfg, initialPath, err := parseAppConfig(os.Args[1:])
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -15,32 +78,78 @@ func main() {
 		os.Exit(1)
 	}
 
+	if cfg.all {
+		os.Exit(exitCode(runBatch(cfg, os.Stdout)))
+	}
+
 	if cfg.once && initialPath == "" {
 		fmt.Fprintln(os.Stderr, "no file to run")
 		os.Exit(1)
 	}
 
-	m := newModel(cfg, initialPath)
-	program := tea.NewProgram(m, tea.WithAltScreen())
+	os.Exit(exitCode(runUI(newModel(cfg, initialPath), nil)))
+}
+
+// usageError is an invalid command line, reported along with the usage of
+// the command. Other errors happen while running and are reported alone.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+
+func (e usageError) Unwrap() error { return e.err }
+
+// usageErrorf formats a usageError.
+func usageErrorf(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// errTestsFailed signals that the final run reported a failure, which has
+// already been printed.
+var errTestsFailed = errors.New("tests failed")
+
+// runUI runs the TUI until it quits and prints the final summary. setup, when
+// provided, is called with the program before it starts.
+func runUI(m model, setup func(*tea.Program)) error {
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if !m.cfg.noMouse {
+		opts = append(opts, tea.WithMouseCellMotion())
+	}
+	program := tea.NewProgram(m, opts...)
+	if setup != nil {
+		setup(program)
+	}
 	finalModel, err := program.Run()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return err
 	}
 
 	fm, ok := finalModel.(model)
 	if !ok {
-		fmt.Fprintln(os.Stderr, "unexpected program state")
-		os.Exit(1)
+		return errors.New("unexpected program state")
 	}
 
 	if fm.summaryErr != nil {
 		fmt.Printf("🚨 Tests passed: %d/%d\n", fm.summaryPassed, fm.summaryTotal)
 		fmt.Fprintln(os.Stderr, fm.summaryErr)
-		os.Exit(1)
+		return errTestsFailed
 	}
 
 	if fm.summaryTotal > 0 {
 		fmt.Printf("🎉 Tests passed: %d/%d\n", fm.summaryPassed, fm.summaryTotal)
 	}
+	return nil
+}
+
+// exitCode reports err on stderr, unless already printed, and maps it to a
+// process exit status.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if !errors.Is(err, errTestsFailed) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return 1
 }
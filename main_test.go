@@ -0,0 +1,20 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestOnlyCommandLineErrorsAreUsageErrors(t *testing.T) {
+	var usage usageError
+	if err := runNewCommand(nil); !errors.As(err, &usage) {
+		t.Fatalf("expected a missing name to be a usage error, got %v", err)
+	}
+	if err := runGenCommand([]string{"--count", "x", "a.cpp"}, io.Discard); !errors.As(err, &usage) {
+		t.Fatalf("expected an invalid flag to be a usage error, got %v", err)
+	}
+	if err := runGenCommand([]string{"missing.cpp"}, io.Discard); err == nil || errors.As(err, &usage) {
+		t.Fatalf("expected a missing source to be a plain error, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// plainFileName matches names that can be used as file names verbatim.
+var plainFileName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+const newUsageMessage = "usage: defi new <name> [--lang cpp] [--dir path] [--template file] [--time-limit D] [--memory-limit MB]"
+
+// runNewCommand scaffolds a solution from a template and watches it.
+func runNewCommand(args []string) error {
+	fs := flag.NewFlagSet("defi new", flag.ContinueOnError)
+	langFlag := fs.String("lang", "cpp", "Solution language, as a file extension")
+	dirFlag := fs.String("dir", ".", "Directory where the solution is created")
+	templateFlag := fs.String("template", "", "Template file to use instead of the configured one")
+	timeLimitFlag := fs.Duration("time-limit", 0, "Time limit written into the defiprompt block")
+	memoryLimitFlag := fs.Int("memory-limit", 0, "Memory limit in megabytes written into the defiprompt block")
+
+	// Accept flags both before and after the problem name.
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() == 0 {
+		return usageErrorf("missing problem name")
+	}
+	name := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	ext := "." + strings.TrimPrefix(*langFlag, ".")
+	if nameExt := filepath.Ext(name); nameExt != "" {
+		if _, ok := supportedLanguages[nameExt]; ok {
+			ext = nameExt
+			name = strings.TrimSuffix(name, nameExt)
+		}
+	}
+	if _, ok := supportedLanguages[ext]; !ok {
+		return usageErrorf("unsupported language %q", *langFlag)
+	}
+
+	limits := PromptLimits{Time: *timeLimitFlag, MemoryMB: *memoryLimitFlag}
+	cfg := appConfig{interval: time.Second, debounce: defaultDebounce, timeLimit: limits.Time, historyDir: defaultHistoryDir}
+	if err := applyUserSettings(&cfg, "", ""); err != nil {
+		return err
+	}
+	content, err := renderSolutionTemplate(ext, *templateFlag, newSolutionData(filepath.Base(name), limits, nil))
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(*dirFlag, filepath.Dir(name))
+	fileName := filepath.Base(name) + ext
+	if !plainFileName.MatchString(fileName) {
+		fileName = solutionFileName(filepath.Base(name), ext)
+	}
+	if _, err := os.Stat(filepath.Join(dir, fileName)); err == nil {
+		return fmt.Errorf("%s already exists", filepath.Join(dir, fileName))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	path, err := createSolutionFile(dir, fileName, content)
+	if err != nil {
+		return err
+	}
+
+	spec, err := parseWatchSpec(path)
+	if err != nil {
+		return err
+	}
+	cfg.spec = spec
+	return runUI(newModel(cfg, path), nil)
+}
@@ -1,16 +1,34 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"strings"
 )
 
+// promptMarker opens every defiprompt comment block.
+const promptMarker = "/*defiprompt"
+
 // PromptCase represents a single parsed prompt with its inputs and expected outputs.
 type PromptCase struct {
 	Inputs  []string
 	Outputs []string
+	// Pending marks a case whose OUTPUT section is empty or "?", waiting to be recorded.
+	Pending bool
+	// External is the ".in" file the case was loaded from, empty for defiprompt cases.
+	External string
+}
+
+// promptCaseSpan locates a parsed case inside the source so its OUTPUT section
+// can be rewritten without touching the surrounding bytes.
+type promptCaseSpan struct {
+	Case PromptCase
+	// outputStart and outputEnd delimit the OUTPUT section body. When the case
+	// has no OUTPUT header both point at the end of the INPUTS section.
+	outputStart int
+	outputEnd   int
+	hasHeader   bool
+	indent      string
 }
 
 // PromptParser extracts prompt test cases from a source file.
@@ -43,82 +61,131 @@ func (p *PromptParser) Parse() ([]PromptCase, error) {
 }
 
 func parsePromptContent(content string) ([]PromptCase, error) {
-	const marker = "/*defiprompt"
+	spans, err := scanPromptContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []PromptCase
+	for _, span := range spans {
+		cases = append(cases, span.Case)
+	}
+	return cases, nil
+}
+
+// scanPromptContent walks every defiprompt block in content and returns the
+// located cases in file order.
+func scanPromptContent(content string) ([]promptCaseSpan, error) {
 	var (
-		cases    []PromptCase
+		spans    []promptCaseSpan
 		searchAt int
 	)
 
 	for {
-		start := strings.Index(content[searchAt:], marker)
+		start := strings.Index(content[searchAt:], promptMarker)
 		if start == -1 {
 			break
 		}
 		start += searchAt
 
-		end := strings.Index(content[start+len(marker):], "*/")
+		end := strings.Index(content[start+len(promptMarker):], "*/")
 		if end == -1 {
 			return nil, fmt.Errorf("unterminated defiprompt block")
 		}
-		end += start + len(marker)
+		end += start + len(promptMarker)
 
-		block := content[start+len(marker) : end]
-		blockCases, err := parsePromptBlock(block)
+		blockSpans, err := scanPromptBlock(content, start+len(promptMarker), end)
 		if err != nil {
 			return nil, err
 		}
-		cases = append(cases, blockCases...)
+		spans = append(spans, blockSpans...)
 
 		searchAt = end + len("*/")
 	}
 
-	return cases, nil
+	return spans, nil
 }
 
-// parsePromptBlock walks through a defiprompt comment, emitting the contained cases.
-func parsePromptBlock(block string) ([]PromptCase, error) {
-	scanner := bufio.NewScanner(strings.NewReader(block))
+// scanPromptBlock walks through a defiprompt comment spanning content[from:to],
+// emitting the contained cases.
+func scanPromptBlock(content string, from, to int) ([]promptCaseSpan, error) {
 	var (
-		cases   []PromptCase
-		current *PromptCase
+		spans   []promptCaseSpan
+		current *promptCaseSpan
 		state   string
+		// lastBodyEnd tracks the end of the last non-blank line of the open section.
+		lastBodyEnd int
 	)
 
+	closeSection := func() {
+		if current == nil {
+			return
+		}
+		switch state {
+		case "input":
+			current.outputStart = lastBodyEnd
+			current.outputEnd = lastBodyEnd
+		case "output":
+			if len(current.Case.Outputs) > 0 {
+				current.outputEnd = lastBodyEnd
+			} else {
+				current.outputEnd = current.outputStart
+			}
+		}
+	}
+
 	flushCurrent := func() error {
 		if current == nil {
 			return nil
 		}
-		if len(current.Inputs) == 0 || len(current.Outputs) == 0 {
-			return fmt.Errorf("incomplete prompt case detected: %+v", *current)
+		closeSection()
+		c := &current.Case
+		if len(c.Inputs) == 0 {
+			return fmt.Errorf("incomplete prompt case detected: %+v", *c)
 		}
-		cases = append(cases, *current)
+		if len(c.Outputs) == 0 || (len(c.Outputs) == 1 && c.Outputs[0] == "?") {
+			c.Outputs = nil
+			c.Pending = true
+		}
+		spans = append(spans, *current)
 		current = nil
 		state = ""
 		return nil
 	}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+	for lineStart := from; lineStart < to; {
+		lineEnd := strings.IndexByte(content[lineStart:to], '\n')
+		next := to
+		if lineEnd == -1 {
+			lineEnd = to
+		} else {
+			lineEnd += lineStart
+			next = lineEnd + 1
+		}
+
+		raw := content[lineStart:lineEnd]
+		line := strings.TrimSpace(raw)
 		if line == "" {
+			lineStart = next
 			continue
 		}
 
 		switch line {
-		case "INPUTS":
-			fallthrough
-		case "INPUTS:":
+		case "INPUTS", "INPUTS:":
 			if err := flushCurrent(); err != nil {
 				return nil, err
 			}
-			current = &PromptCase{}
+			current = &promptCaseSpan{}
 			state = "input"
-		case "OUTPUT":
-			fallthrough
-		case "OUTPUT:":
+		case "OUTPUT", "OUTPUT:":
 			if current == nil {
 				return nil, fmt.Errorf("OUTPUT encountered before INPUTS")
 			}
+			closeSection()
 			state = "output"
+			current.hasHeader = true
+			current.outputStart = next
+			current.indent = raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
 		case "-*-":
 			if err := flushCurrent(); err != nil {
 				return nil, err
@@ -126,22 +193,25 @@ func parsePromptBlock(block string) ([]PromptCase, error) {
 		default:
 			switch state {
 			case "input":
-				current.Inputs = append(current.Inputs, line)
+				current.Case.Inputs = append(current.Case.Inputs, line)
+				if current.indent == "" {
+					current.indent = raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
+				}
+				lastBodyEnd = next
 			case "output":
-				current.Outputs = append(current.Outputs, line)
+				current.Case.Outputs = append(current.Case.Outputs, line)
+				lastBodyEnd = next
 			default:
 				// Ignore stray lines outside a known section.
 			}
 		}
-	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+		lineStart = next
 	}
 
 	if err := flushCurrent(); err != nil {
 		return nil, err
 	}
 
-	return cases, nil
+	return spans, nil
 }
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// rewritePromptOutputs replaces the OUTPUT section of the cases keyed by their
+// zero-based index, leaving every other byte of content untouched.
+func rewritePromptOutputs(content string, outputs map[int][]string) (string, error) {
+	spans, err := scanPromptContent(content)
+	if err != nil {
+		return "", err
+	}
+
+	indices := make([]int, 0, len(outputs))
+	for idx := range outputs {
+		if idx < 0 || idx >= len(spans) {
+			return "", fmt.Errorf("case %d not found in defiprompt blocks", idx+1)
+		}
+		if err := checkPromptLines("output", outputs[idx]); err != nil {
+			return "", fmt.Errorf("case %d: %w", idx+1, err)
+		}
+		indices = append(indices, idx)
+	}
+	// Apply from the end of the file so earlier offsets stay valid.
+	sort.Sort(sort.Reverse(sort.IntSlice(indices)))
+
+	newline := detectNewline(content)
+	for _, idx := range indices {
+		span := spans[idx]
+		var b strings.Builder
+		if !span.hasHeader {
+			b.WriteString(span.indent + "OUTPUT:" + newline)
+		}
+		for _, line := range outputs[idx] {
+			b.WriteString(span.indent + line + newline)
+		}
+		replacement := b.String()
+		if span.outputStart > 0 && content[span.outputStart-1] != '\n' {
+			// The section ran up to the closing marker on the same line.
+			replacement = newline + replacement
+		}
+		content = content[:span.outputStart] + replacement + content[span.outputEnd:]
+	}
+
+	return content, nil
+}
+
+// writePromptOutputs rewrites the OUTPUT sections of the given cases in the
+// file at path, preserving its permissions.
+func writePromptOutputs(path string, outputs map[int][]string) error {
+	return updateSourceFile(path, func(content string) (string, error) {
+		return rewritePromptOutputs(content, outputs)
+	})
+}
+
+// appendPromptCase adds a case to the last defiprompt block in content, or
+// creates a new block at the end when none exists. Empty outputs produce a
+// pending case ready to be recorded.
+func appendPromptCase(content string, inputs, outputs []string) (string, error) {
+	if len(inputs) == 0 {
+		return "", fmt.Errorf("a prompt case needs at least one input line")
+	}
+	if err := checkPromptLines("input", inputs); err != nil {
+		return "", err
+	}
+	if err := checkPromptLines("output", outputs); err != nil {
+		return "", err
+	}
+
+	newline := detectNewline(content)
+	var body strings.Builder
+	writeSection := func(indent, header string, lines []string) {
+		body.WriteString(indent + header + newline)
+		for _, line := range lines {
+			body.WriteString(indent + line + newline)
+		}
+	}
+	if len(outputs) == 0 {
+		outputs = []string{"?"}
+	}
+
+	start := strings.LastIndex(content, promptMarker)
+	if start == -1 {
+		writeSection("", "INPUTS:", inputs)
+		writeSection("", "OUTPUT:", outputs)
+		prefix := ""
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			prefix = newline
+		}
+		return content + prefix + newline + promptMarker + newline + body.String() + "*/" + newline, nil
+	}
+
+	end := strings.Index(content[start+len(promptMarker):], "*/")
+	if end == -1 {
+		return "", fmt.Errorf("unterminated defiprompt block")
+	}
+	end += start + len(promptMarker)
+
+	spans, err := scanPromptBlock(content, start+len(promptMarker), end)
+	if err != nil {
+		return "", err
+	}
+
+	indent := ""
+	if len(spans) > 0 {
+		indent = spans[len(spans)-1].indent
+		body.WriteString(indent + "-*-" + newline)
+	}
+	writeSection(indent, "INPUTS:", inputs)
+	writeSection(indent, "OUTPUT:", outputs)
+
+	// Insert right after the last line break before the closing marker so the
+	// marker keeps its own line and indentation.
+	insertAt := strings.LastIndex(content[:end], "\n") + 1
+	prefix := ""
+	if strings.TrimSpace(content[insertAt:end]) != "" || insertAt <= start {
+		insertAt = end
+		prefix = newline
+	}
+	return content[:insertAt] + prefix + body.String() + content[insertAt:], nil
+}
+
+// checkPromptLines reports lines of a section that would not read back as
+// written: the parser skips blank lines, stops the block at "*/", takes
+// headers and "-*-" for structure and a lone "?" output for a pending case.
+func checkPromptLines(section string, lines []string) error {
+	for i, line := range lines {
+		switch trimmed := strings.TrimSpace(line); {
+		case trimmed == "":
+			return fmt.Errorf("%s line %d is blank", section, i+1)
+		case strings.Contains(line, "*/"):
+			return fmt.Errorf("%s line %d contains */", section, i+1)
+		case trimmed == "-*-", trimmed == "INPUTS", trimmed == "INPUTS:", trimmed == "OUTPUT", trimmed == "OUTPUT:":
+			return fmt.Errorf("%s line %d reads as %s", section, i+1, trimmed)
+		}
+	}
+	if section == "output" && len(lines) == 1 && strings.TrimSpace(lines[0]) == "?" {
+		return fmt.Errorf("a ? output reads as pending")
+	}
+	return nil
+}
+
+// updateSourceFile applies edit to the contents of path and writes the result back.
+func updateSourceFile(path string, edit func(string) (string, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to access %q: %w", path, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	updated, err := edit(string(data))
+	if err != nil {
+		return err
+	}
+	if updated == string(data) {
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(updated), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %q: %w", path, err)
+	}
+	return nil
+}
+
+func detectNewline(content string) string {
+	if strings.Contains(content, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
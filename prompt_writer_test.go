@@ -0,0 +1,224 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePromptContentPendingCases(t *testing.T) {
+	script := `/*defiprompt
+INPUTS:
+1
+OUTPUT:
+?
+-*-
+INPUTS:
+2
+OUTPUT:
+-*-
+INPUTS:
+3
+*/`
+
+	cases, err := parsePromptContent(script)
+	if err != nil {
+		t.Fatalf("parsePromptContent returned error: %v", err)
+	}
+
+	if len(cases) != 3 {
+		t.Fatalf("expected 3 cases, got %d", len(cases))
+	}
+
+	for i, c := range cases {
+		if !c.Pending || c.Outputs != nil {
+			t.Fatalf("expected case %d to be pending, got %#v", i+1, c)
+		}
+	}
+}
+
+func TestRewritePromptOutputs(t *testing.T) {
+	script := `#include <iostream>
+// keep me   
+/*defiprompt
+INPUTS:
+1
+OUTPUT:
+?
+-*-
+  INPUTS:
+  2
+  OUTPUT:
+
+-*-
+INPUTS:
+3
+OUTPUT:
+9
+-*-
+INPUTS:
+4
+*/
+int main() {}
+`
+
+	got, err := rewritePromptOutputs(script, map[int][]string{
+		0: {"1"},
+		1: {"4", "4"},
+		3: {"16"},
+	})
+	if err != nil {
+		t.Fatalf("rewritePromptOutputs returned error: %v", err)
+	}
+
+	want := `#include <iostream>
+// keep me   
+/*defiprompt
+INPUTS:
+1
+OUTPUT:
+1
+-*-
+  INPUTS:
+  2
+  OUTPUT:
+  4
+  4
+
+-*-
+INPUTS:
+3
+OUTPUT:
+9
+-*-
+INPUTS:
+4
+OUTPUT:
+16
+*/
+int main() {}
+`
+	if got != want {
+		t.Fatalf("unexpected rewrite:\n%s", got)
+	}
+
+	cases, err := parsePromptContent(got)
+	if err != nil {
+		t.Fatalf("rewritten content does not parse: %v", err)
+	}
+	if !reflect.DeepEqual(cases[1], PromptCase{Inputs: []string{"2"}, Outputs: []string{"4", "4"}}) {
+		t.Fatalf("unexpected second case: %#v", cases[1])
+	}
+}
+
+func TestRewritePromptOutputsRoundTrips(t *testing.T) {
+	script := "/*defiprompt\nINPUTS:\n1\nOUTPUT:\n?\n-*-\nINPUTS:\n2\nOUTPUT:\n2\n*/\n"
+
+	for _, outputs := range [][]string{
+		{"1 2 3"},
+		{"-1", "*", "/*"},
+		{"?", "?"},
+		{"INPUTS: 3", "-*- -*-"},
+	} {
+		got, err := rewritePromptOutputs(script, map[int][]string{0: outputs})
+		if err != nil {
+			t.Fatalf("rewritePromptOutputs(%q): %v", outputs, err)
+		}
+		cases, err := parsePromptContent(got)
+		if err != nil {
+			t.Fatalf("rewritten content does not parse: %v\n%s", err, got)
+		}
+		if len(cases) != 2 || !reflect.DeepEqual(cases[0].Outputs, outputs) || cases[1].Outputs[0] != "2" {
+			t.Fatalf("expected %q to read back, got %#v", outputs, cases)
+		}
+	}
+
+	for _, outputs := range [][]string{
+		{"1", "", "2"},
+		{"a */ b"},
+		{"-*-"},
+		{"OUTPUT:"},
+		{"  INPUTS"},
+		{"?"},
+	} {
+		if got, err := rewritePromptOutputs(script, map[int][]string{0: outputs}); err == nil {
+			t.Fatalf("expected %q to be rejected, got:\n%s", outputs, got)
+		}
+	}
+	if _, err := appendPromptCase(script, []string{"1", "*/"}, nil); err == nil {
+		t.Fatal("expected an input ending the block to be rejected")
+	}
+}
+
+func TestWritePromptOutputsKeepsCRLF(t *testing.T) {
+	script := "/*defiprompt\r\nINPUTS:\r\n5\r\nOUTPUT:\r\n?\r\n*/\r\n"
+
+	dir := t.TempDir()
+	scriptPath := filepath.Join(dir, "sample.cpp")
+	if err := os.WriteFile(scriptPath, []byte(script), 0o600); err != nil {
+		t.Fatalf("failed to write temp script: %v", err)
+	}
+
+	if err := writePromptOutputs(scriptPath, map[int][]string{0: {"25"}}); err != nil {
+		t.Fatalf("writePromptOutputs returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(scriptPath)
+	if err != nil {
+		t.Fatalf("failed to read script: %v", err)
+	}
+	if want := "/*defiprompt\r\nINPUTS:\r\n5\r\nOUTPUT:\r\n25\r\n*/\r\n"; string(data) != want {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+func TestAppendPromptCase(t *testing.T) {
+	script := `/*defiprompt
+INPUTS:
+1
+OUTPUT:
+1
+*/
+int main() {}
+`
+
+	got, err := appendPromptCase(script, []string{"2", "3"}, nil)
+	if err != nil {
+		t.Fatalf("appendPromptCase returned error: %v", err)
+	}
+
+	want := `/*defiprompt
+INPUTS:
+1
+OUTPUT:
+1
+-*-
+INPUTS:
+2
+3
+OUTPUT:
+?
+*/
+int main() {}
+`
+	if got != want {
+		t.Fatalf("unexpected content:\n%s", got)
+	}
+}
+
+func TestAppendPromptCaseCreatesBlock(t *testing.T) {
+	got, err := appendPromptCase("int main() {}", []string{"7"}, []string{"49"})
+	if err != nil {
+		t.Fatalf("appendPromptCase returned error: %v", err)
+	}
+
+	cases, err := parsePromptContent(got)
+	if err != nil {
+		t.Fatalf("appended content does not parse: %v", err)
+	}
+	want := []PromptCase{{Inputs: []string{"7"}, Outputs: []string{"49"}}}
+	if !reflect.DeepEqual(cases, want) {
+		t.Fatalf("unexpected cases: %#v", cases)
+	}
+}
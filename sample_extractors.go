@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// sampleExtractor pulls sample input/output pairs out of a problem page.
+type sampleExtractor interface {
+	// Name identifies the extractor for the --site flag.
+	Name() string
+	// Matches reports whether the extractor understands the page.
+	Matches(pageURL, page string) bool
+	// Extract returns the samples found in the page.
+	Extract(page string) ([]PromptCase, error)
+}
+
+// sampleExtractors lists the known site parsers, most specific first.
+var sampleExtractors = []sampleExtractor{
+	codeforcesExtractor{},
+	atcoderExtractor{},
+	leetcodeExtractor{},
+	genericExtractor{},
+}
+
+// findSampleExtractor returns the extractor named site, or the first one
+// matching the page when site is empty.
+func findSampleExtractor(site, pageURL, page string) (sampleExtractor, error) {
+	for _, ex := range sampleExtractors {
+		if site != "" {
+			if ex.Name() == site {
+				return ex, nil
+			}
+			continue
+		}
+		if ex.Matches(pageURL, page) {
+			return ex, nil
+		}
+	}
+
+	if site != "" {
+		names := make([]string, len(sampleExtractors))
+		for i, ex := range sampleExtractors {
+			names[i] = ex.Name()
+		}
+		return nil, fmt.Errorf("unknown site %q (available: %s)", site, strings.Join(names, ", "))
+	}
+	return nil, fmt.Errorf("no site parser recognises this page")
+}
+
+var (
+	preBlockRe  = regexp.MustCompile(`(?is)<pre[^>]*>(.*?)</pre>`)
+	lineBreakRe = regexp.MustCompile(`(?i)<br\s*/?>|</div>|</p>`)
+	tagRe       = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// htmlText converts an HTML fragment into plain text lines.
+func htmlText(fragment string) []string {
+	text := lineBreakRe.ReplaceAllString(fragment, "\n")
+	text = html.UnescapeString(tagRe.ReplaceAllString(text, ""))
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// pairSamples zips inputs with outputs, rejecting pages with mismatched counts.
+func pairSamples(inputs, outputs [][]string) ([]PromptCase, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no samples found")
+	}
+	if len(inputs) != len(outputs) {
+		return nil, fmt.Errorf("found %d sample inputs but %d outputs", len(inputs), len(outputs))
+	}
+
+	cases := make([]PromptCase, len(inputs))
+	for i := range inputs {
+		cases[i] = PromptCase{Inputs: inputs[i], Outputs: outputs[i]}
+	}
+	return cases, nil
+}
+
+// codeforcesExtractor reads the "sample-test" blocks of Codeforces problems.
+type codeforcesExtractor struct{}
+
+var cfSectionRe = regexp.MustCompile(`(?is)<div class="(input|output)">.*?<pre[^>]*>(.*?)</pre>`)
+
+func (codeforcesExtractor) Name() string { return "codeforces" }
+
+func (codeforcesExtractor) Matches(pageURL, page string) bool {
+	return strings.Contains(pageURL, "codeforces.") || strings.Contains(page, `class="sample-test"`)
+}
+
+func (codeforcesExtractor) Extract(page string) ([]PromptCase, error) {
+	var inputs, outputs [][]string
+	for _, m := range cfSectionRe.FindAllStringSubmatch(page, -1) {
+		if strings.EqualFold(m[1], "input") {
+			inputs = append(inputs, htmlText(m[2]))
+		} else {
+			outputs = append(outputs, htmlText(m[2]))
+		}
+	}
+	return pairSamples(inputs, outputs)
+}
+
+// atcoderExtractor reads "Sample Input N" / "Sample Output N" sections,
+// falling back to the Japanese headings when the page has no English part.
+type atcoderExtractor struct{}
+
+var atcoderSectionRe = regexp.MustCompile(`(?is)<h3>\s*(Sample Input|Sample Output|入力例|出力例)\s*\d*\s*</h3>\s*<pre[^>]*>(.*?)</pre>`)
+
+func (atcoderExtractor) Name() string { return "atcoder" }
+
+func (atcoderExtractor) Matches(pageURL, page string) bool {
+	return strings.Contains(pageURL, "atcoder.jp") || atcoderSectionRe.MatchString(page)
+}
+
+func (atcoderExtractor) Extract(page string) ([]PromptCase, error) {
+	var enIn, enOut, jaIn, jaOut [][]string
+	for _, m := range atcoderSectionRe.FindAllStringSubmatch(page, -1) {
+		lines := htmlText(m[2])
+		switch m[1] {
+		case "Sample Input":
+			enIn = append(enIn, lines)
+		case "Sample Output":
+			enOut = append(enOut, lines)
+		case "入力例":
+			jaIn = append(jaIn, lines)
+		case "出力例":
+			jaOut = append(jaOut, lines)
+		}
+	}
+	if len(enIn) > 0 {
+		return pairSamples(enIn, enOut)
+	}
+	return pairSamples(jaIn, jaOut)
+}
+
+// leetcodeExtractor turns "Input: a = 1, b = [2]" examples into one value
+// per line, in declaration order.
+type leetcodeExtractor struct{}
+
+var leetcodeExampleRe = regexp.MustCompile(`(?is)<strong>\s*Input:?\s*</strong>:?(.*?)<strong>\s*Output:?\s*</strong>:?(.*?)(?:<strong>|</pre>|$)`)
+
+func (leetcodeExtractor) Name() string { return "leetcode" }
+
+func (leetcodeExtractor) Matches(pageURL, page string) bool {
+	return strings.Contains(pageURL, "leetcode.") || leetcodeExampleRe.MatchString(page)
+}
+
+func (leetcodeExtractor) Extract(page string) ([]PromptCase, error) {
+	var inputs, outputs [][]string
+	for _, m := range leetcodeExampleRe.FindAllStringSubmatch(page, -1) {
+		inputs = append(inputs, leetcodeValues(strings.Join(htmlText(m[1]), " ")))
+		outputs = append(outputs, htmlText(m[2]))
+	}
+	return pairSamples(inputs, outputs)
+}
+
+// leetcodeValues splits "nums = [2,7], target = 9" into ["[2,7]", "9"],
+// ignoring commas nested in brackets or quotes.
+func leetcodeValues(assignments string) []string {
+	var (
+		values  []string
+		depth   int
+		quoted  bool
+		current strings.Builder
+	)
+	flush := func() {
+		part := strings.TrimSpace(current.String())
+		if eq := strings.Index(part, "="); eq != -1 && !strings.ContainsAny(part[:eq], `"[{(`) {
+			part = strings.TrimSpace(part[eq+1:])
+		}
+		if part != "" {
+			values = append(values, part)
+		}
+		current.Reset()
+	}
+
+	for _, r := range assignments {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[' || r == '{' || r == '(':
+			depth++
+		case r == ']' || r == '}' || r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return values
+}
+
+// genericExtractor pairs <pre> blocks whose preceding text mentions an input
+// or an output, covering simple judges without a dedicated parser.
+type genericExtractor struct{}
+
+func (genericExtractor) Name() string { return "generic" }
+
+func (genericExtractor) Matches(string, string) bool { return true }
+
+func (genericExtractor) Extract(page string) ([]PromptCase, error) {
+	var inputs, outputs [][]string
+	last := 0
+	for _, loc := range preBlockRe.FindAllStringSubmatchIndex(page, -1) {
+		context := strings.ToLower(strings.Join(htmlText(page[last:loc[0]]), " "))
+		last = loc[1]
+		inIdx, outIdx := strings.LastIndex(context, "input"), strings.LastIndex(context, "output")
+		if inIdx == -1 && outIdx == -1 {
+			continue
+		}
+		lines := htmlText(page[loc[2]:loc[3]])
+		if inIdx > outIdx {
+			inputs = append(inputs, lines)
+		} else {
+			outputs = append(outputs, lines)
+		}
+	}
+	return pairSamples(inputs, outputs)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// userSettings are the preferences kept in the user's config file.
+type userSettings struct {
+	Layout string `json:"layout,omitempty"`
+	// Theme names a built-in theme, one of Themes or "auto".
+	Theme string `json:"theme,omitempty"`
+	// Themes defines custom themes by name; see resolveTheme.
+	Themes map[string]json.RawMessage `json:"themes,omitempty"`
+	// Keys rebinds keys by binding name, such as "accept": ["A"].
+	Keys map[string][]string `json:"keys,omitempty"`
+}
+
+// settingsSavedMsg reports the outcome of saving a setting.
+type settingsSavedMsg struct {
+	err error
+}
+
+// userSettingsPath is the config file, next to the user template directory.
+func userSettingsPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "defi", "config.json"), nil
+}
+
+// loadUserSettings reads the config file at path. A missing file yields the
+// defaults.
+func loadUserSettings(path string) (userSettings, error) {
+	var settings userSettings
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// saveUserSetting sets key to value in the config file at path, keeping every
+// other entry, including ones this version does not know about.
+func saveUserSetting(path, key string, value any) error {
+	entries := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return fmt.Errorf("invalid config %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	entries[key] = encoded
+
+	data, err = json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// saveUserSettingCmd saves a setting in the background. Without a config
+// path, as in tests, nothing is saved.
+func saveUserSettingCmd(path, key string, value any) tea.Cmd {
+	if path == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		return settingsSavedMsg{err: saveUserSetting(path, key, value)}
+	}
+}
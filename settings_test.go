@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveUserSettingKeepsOtherEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "defi", "config.json")
+	if settings, err := loadUserSettings(path); err != nil || settings.Layout != "" {
+		t.Fatalf("expected defaults without a config file, got %+v, %v", settings, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"future": {"x": 1}, "layout": "list"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveUserSetting(path, "layout", "maximized"); err != nil {
+		t.Fatalf("saveUserSetting: %v", err)
+	}
+
+	settings, err := loadUserSettings(path)
+	if err != nil || settings.Layout != "maximized" {
+		t.Fatalf("expected the new layout, got %+v, %v", settings, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"future"`) {
+		t.Fatalf("expected unknown entries to be kept, got:\n%s", data)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const shrinkUsageMessage = "usage: defi shrink <solution> [--case N] [--ref file] [--validator file] [--checker spec] [--time-limit D] [--compile-flags F]"
+
+// failureKind tells how a solution failed on an input. Shrinking keeps the
+// kind of the original failure so that, say, a wrong answer does not turn
+// into a crash on a malformed input.
+type failureKind int
+
+const (
+	failureNone failureKind = iota
+	// failureRuntime covers crashes and exceeded limits.
+	failureRuntime
+	failureWrongAnswer
+)
+
+// shrinkOptions configures the programs used to judge candidate inputs.
+type shrinkOptions struct {
+	solution string
+	// reference, when set, provides the expected answer of every candidate.
+	reference string
+	// validator, when set, must exit with status 0 for a candidate to be tried.
+	validator    string
+	checker      string
+	timeLimit    time.Duration
+	compileFlags []string
+}
+
+// shrinker minimizes failing inputs with compiled copies of the programs.
+type shrinker struct {
+	dir       string
+	solution  string
+	reference string
+	validator string
+	checker   outputChecker
+	limits    PromptLimits
+	// programChecker reports whether the checker is an external program,
+	// which can judge outputs without a reference answer.
+	programChecker bool
+}
+
+// shrinkResult is the smallest input found that still fails like the original.
+type shrinkResult struct {
+	Index    int
+	Inputs   []string
+	Expected []string
+	Actual   []string
+	Err      error
+	// Runs counts the candidates tried.
+	Runs int
+}
+
+func runShrinkCommand(args []string) error {
+	fs := flag.NewFlagSet("defi shrink", flag.ContinueOnError)
+	caseFlag := fs.Int("case", 0, "Case to shrink, counting from 1 (default: the first failing case)")
+	refFlag := fs.String("ref", "", "Reference solution giving the expected answer of shrunk inputs")
+	validatorFlag := fs.String("validator", "", "Program that exits with status 0 for well-formed inputs")
+	checkerFlag := fs.String("checker", "", "Output checker: exact, tokens, float[:EPS] or a checker program")
+	timeLimitFlag := fs.Duration("time-limit", 0, "Per-run time limit when the source declares none")
+	compileFlagsFlag := fs.String("compile-flags", "", "Override compiler flags (space-separated)")
+
+	// Accept flags both before and after the solution.
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() == 0 {
+		return usageErrorf("missing solution file")
+	}
+	solution := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	cases, err := loadTestSuite(solution)
+	if err != nil {
+		return err
+	}
+	if *caseFlag < 0 || *caseFlag > len(cases) {
+		return fmt.Errorf("case %d not found", *caseFlag)
+	}
+
+	ctx := context.Background()
+	s, err := newShrinker(ctx, shrinkOptions{
+		solution:     solution,
+		reference:    *refFlag,
+		validator:    *validatorFlag,
+		checker:      *checkerFlag,
+		timeLimit:    *timeLimitFlag,
+		compileFlags: strings.Fields(*compileFlagsFlag),
+	})
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	idx := *caseFlag - 1
+	if idx < 0 {
+		if idx, err = s.firstFailing(ctx, cases); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Shrinking case %d (%d input lines)...\n", idx+1, len(cases[idx].Inputs))
+	result, err := s.shrink(ctx, idx, cases[idx])
+	if err != nil {
+		return err
+	}
+	printShrinkResult(os.Stdout, result)
+	return nil
+}
+
+// newShrinker compiles the solution and the optional reference, validator
+// and checker into a temporary directory.
+func newShrinker(ctx context.Context, opts shrinkOptions) (*shrinker, error) {
+	dir, err := os.MkdirTemp("", "defi-shrink-")
+	if err != nil {
+		return nil, err
+	}
+	s := &shrinker{dir: dir}
+
+	if s.limits, err = loadPromptLimits(opts.solution); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if s.limits.Time == 0 {
+		s.limits.Time = opts.timeLimit
+	}
+	s.limits = s.limits.withCompileFlags(opts.compileFlags)
+
+	programs := []struct {
+		name string
+		path string
+		dest *string
+	}{
+		{"solution", opts.solution, &s.solution},
+		{"reference", opts.reference, &s.reference},
+		{"validator", opts.validator, &s.validator},
+	}
+	for _, p := range programs {
+		if p.path == "" {
+			continue
+		}
+		if *p.dest, err = prepareProgram(ctx, p.path, dir, p.name, opts.compileFlags); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	if s.checker, err = newChecker(ctx, opts.checker, dir); err != nil {
+		s.Close()
+		return nil, err
+	}
+	_, s.programChecker = s.checker.(programChecker)
+	return s, nil
+}
+
+// Close removes the compiled programs.
+func (s *shrinker) Close() {
+	os.RemoveAll(s.dir)
+}
+
+// firstFailing returns the index of the first case the solution fails.
+func (s *shrinker) firstFailing(ctx context.Context, cases []PromptCase) (int, error) {
+	for i, c := range cases {
+		if c.Pending && s.reference == "" {
+			continue
+		}
+		kind, _, _, _, err := s.judge(ctx, c.Inputs, c.Outputs)
+		if err != nil {
+			return 0, err
+		}
+		if kind != failureNone {
+			return i, nil
+		}
+	}
+	return 0, errors.New("no failing case to shrink")
+}
+
+// judge runs the solution on inputs and classifies the outcome. The expected
+// answer comes from the reference when there is one, else from expected.
+func (s *shrinker) judge(ctx context.Context, inputs, expected []string) (kind failureKind, answer, actual []string, failure error, err error) {
+	answer = expected
+	if s.reference != "" {
+		if answer, err = execProgram(ctx, s.reference, nil, inputs, PromptLimits{}); err != nil {
+			return failureNone, nil, nil, nil, fmt.Errorf("reference: %w", err)
+		}
+	}
+
+	actual, failure = execProgram(ctx, s.solution, nil, inputs, s.limits)
+	if failure != nil {
+		return failureRuntime, answer, nil, failure, nil
+	}
+	if failure = s.checker.Check(inputs, answer, actual); failure != nil {
+		return failureWrongAnswer, answer, actual, failure, nil
+	}
+	return failureNone, answer, actual, nil, nil
+}
+
+// valid reports whether the validator, if any, accepts inputs.
+func (s *shrinker) valid(ctx context.Context, inputs []string) bool {
+	if s.validator == "" {
+		return true
+	}
+	_, err := execProgram(ctx, s.validator, nil, inputs, PromptLimits{})
+	return err == nil
+}
+
+// shrink minimizes the input of c, first by whole lines and then by tokens,
+// keeping only candidates that are valid and fail the same way as c.
+func (s *shrinker) shrink(ctx context.Context, idx int, c PromptCase) (shrinkResult, error) {
+	if !s.valid(ctx, c.Inputs) {
+		return shrinkResult{}, fmt.Errorf("case %d: the validator rejects the original input", idx+1)
+	}
+	kind, answer, actual, failure, err := s.judge(ctx, c.Inputs, c.Outputs)
+	if err != nil {
+		return shrinkResult{}, err
+	}
+	if kind == failureNone {
+		return shrinkResult{}, fmt.Errorf("case %d passes, nothing to shrink", idx+1)
+	}
+	if kind == failureWrongAnswer && s.reference == "" && !s.programChecker {
+		return shrinkResult{}, errors.New("shrinking a wrong answer needs --ref or a checker program to judge smaller inputs")
+	}
+
+	result := shrinkResult{Index: idx, Inputs: c.Inputs, Expected: answer, Actual: actual, Err: failure}
+	fails := func(inputs []string) (bool, error) {
+		if ctx.Err() != nil {
+			return false, errRunCanceled
+		}
+		result.Runs++
+		if len(inputs) == 0 || !s.valid(ctx, inputs) {
+			return false, nil
+		}
+		// The original answer does not belong to a smaller input: the
+		// reference gives one, else the checker program judges without it.
+		k, answer, actual, failure, err := s.judge(ctx, inputs, nil)
+		if err != nil || k != kind {
+			// A reference that cannot handle the candidate rules it out.
+			return false, nil
+		}
+		result.Inputs, result.Expected, result.Actual, result.Err = inputs, answer, actual, failure
+		return true, nil
+	}
+
+	lines, err := ddmin(c.Inputs, fails)
+	if err != nil {
+		return result, err
+	}
+
+	// result always holds the last candidate that failed, which is the
+	// smallest one found.
+	_, err = ddmin(lineTokens(lines), func(tokens []inputToken) (bool, error) {
+		return fails(joinTokens(tokens))
+	})
+	return result, err
+}
+
+// ddmin removes chunks of items, halving the chunk size whenever no removal
+// keeps the failure, until no single item can be removed.
+func ddmin[T any](items []T, fails func([]T) (bool, error)) ([]T, error) {
+	n := 2
+	for len(items) >= 2 {
+		chunk := (len(items) + n - 1) / n
+		reduced := false
+		for start := 0; start < len(items); start += chunk {
+			end := min(start+chunk, len(items))
+			candidate := append(append([]T{}, items[:start]...), items[end:]...)
+			ok, err := fails(candidate)
+			if err != nil {
+				return items, err
+			}
+			if ok {
+				items = candidate
+				n = max(n-1, 2)
+				reduced = true
+				break
+			}
+		}
+		if !reduced {
+			if n >= len(items) {
+				break
+			}
+			n = min(n*2, len(items))
+		}
+	}
+	return items, nil
+}
+
+// inputToken is a whitespace separated token and the input line it is on.
+type inputToken struct {
+	line int
+	text string
+}
+
+func lineTokens(lines []string) []inputToken {
+	var tokens []inputToken
+	for i, line := range lines {
+		for _, field := range strings.Fields(line) {
+			tokens = append(tokens, inputToken{line: i, text: field})
+		}
+	}
+	return tokens
+}
+
+// joinTokens rebuilds input lines, dropping lines left without tokens.
+func joinTokens(tokens []inputToken) []string {
+	var lines []string
+	for i, t := range tokens {
+		if i > 0 && tokens[i-1].line == t.line {
+			lines[len(lines)-1] += " " + t.text
+			continue
+		}
+		lines = append(lines, t.text)
+	}
+	return lines
+}
+
+func printShrinkResult(out io.Writer, r shrinkResult) {
+	fmt.Fprintf(out, "Case %d shrunk to %d input lines after %d runs: %v\n", r.Index+1, len(r.Inputs), r.Runs, r.Err)
+	sections := []struct {
+		title string
+		lines []string
+	}{{"INPUTS:", r.Inputs}, {"EXPECTED:", r.Expected}, {"OUTPUT:", r.Actual}}
+	for _, section := range sections {
+		fmt.Fprintln(out, section.title)
+		for _, line := range section.lines {
+			fmt.Fprintln(out, line)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestDdminFindsMinimalSubset(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	got, err := ddmin(items, func(c []int) (bool, error) {
+		return slices.Contains(c, 3) && slices.Contains(c, 8), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{3, 8}) {
+		t.Fatalf("ddmin = %v, want [3 8]", got)
+	}
+}
+
+func TestShrinkWrongAnswerWithReference(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newShrinker(context.Background(), shrinkOptions{
+		solution:  writeScript(t, dir, "sol.sh", `n=0; while read -r line; do for tok in $line; do n=$((n+1)); [ "$tok" = 7 ] && n=$((n+1)); done; done; echo $n`),
+		reference: writeScript(t, dir, "ref.sh", `wc -w | tr -d ' '`),
+	})
+	if err != nil {
+		t.Fatalf("newShrinker: %v", err)
+	}
+	defer s.Close()
+
+	c := PromptCase{Inputs: []string{"1 2 3", "4 5 6", "7 8 9", "10 11 12"}, Outputs: []string{"12"}}
+	result, err := s.shrink(context.Background(), 0, c)
+	if err != nil {
+		t.Fatalf("shrink: %v", err)
+	}
+	if !slices.Equal(result.Inputs, []string{"7"}) {
+		t.Fatalf("shrunk input = %q, want [7]", result.Inputs)
+	}
+	if !slices.Equal(result.Expected, []string{"1"}) || !slices.Equal(result.Actual, []string{"2"}) {
+		t.Fatalf("unexpected outputs %q / %q", result.Expected, result.Actual)
+	}
+}
+
+func TestShrinkWrongAnswerWithCheckerProgram(t *testing.T) {
+	dir := t.TempDir()
+	// Like testlib checkers, it compares with the answer when there is one
+	// and otherwise judges the output from the input alone.
+	checker := writeScript(t, dir, "check.sh", `want=$(cat "$3"); [ -n "$want" ] || want=$(wc -w < "$1" | tr -d ' '); [ "$(cat "$2")" = "$want" ] || { echo "wrong answer"; exit 1; }`)
+	s, err := newShrinker(context.Background(), shrinkOptions{
+		solution: writeScript(t, dir, "sol.sh", `n=0; while read -r line; do for tok in $line; do n=$((n+1)); [ "$tok" = 7 ] && n=$((n+1)); done; done; echo $n`),
+		checker:  checker,
+	})
+	if err != nil {
+		t.Fatalf("newShrinker: %v", err)
+	}
+	defer s.Close()
+
+	c := PromptCase{Inputs: []string{"1 2 3", "4 5 6", "7 8 9", "10 11 12"}, Outputs: []string{"12"}}
+	result, err := s.shrink(context.Background(), 0, c)
+	if err != nil {
+		t.Fatalf("shrink: %v", err)
+	}
+	if !slices.Equal(result.Inputs, []string{"7"}) {
+		t.Fatalf("shrunk input = %q, want [7]", result.Inputs)
+	}
+	if result.Expected != nil || !slices.Equal(result.Actual, []string{"2"}) {
+		t.Fatalf("unexpected outputs %q / %q", result.Expected, result.Actual)
+	}
+}
+
+func TestShrinkRespectsValidator(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newShrinker(context.Background(), shrinkOptions{
+		solution:  writeScript(t, dir, "sol.sh", `grep -q boom && exit 1; echo ok`),
+		validator: writeScript(t, dir, "valid.sh", `[ "$(wc -l)" -ge 2 ]`),
+	})
+	if err != nil {
+		t.Fatalf("newShrinker: %v", err)
+	}
+	defer s.Close()
+
+	c := PromptCase{Inputs: []string{"a", "b", "boom", "c", "d"}, Outputs: []string{"ok"}}
+	result, err := s.shrink(context.Background(), 0, c)
+	if err != nil {
+		t.Fatalf("shrink: %v", err)
+	}
+	if len(result.Inputs) != 2 || !slices.Contains(result.Inputs, "boom") {
+		t.Fatalf("shrunk input = %q, want two lines including boom", result.Inputs)
+	}
+}
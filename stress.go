@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const stressUsageMessage = "usage: defi stress <solution> --brute file [--gen file] [--checker spec] [--seed N] [--iterations N] [--time-limit D] [--compile-flags F]"
+
+// stressOptions configures a stress testing session.
+type stressOptions struct {
+	solution  string
+	brute     string
+	generator string
+	checker   string
+	// seed is the first generator seed; iterations bounds the search, zero
+	// meaning until a mismatch is found.
+	seed         int
+	iterations   int
+	timeLimit    time.Duration
+	compileFlags []string
+}
+
+// stressSession holds the compiled programs of a stress test.
+type stressSession struct {
+	dir       string
+	solution  string
+	brute     string
+	generator string
+	// genSpec replaces the generator program when the sources declare GEN.
+	genSpec *genSpec
+	checker outputChecker
+	limits  PromptLimits
+}
+
+// stressReadyMsg reports that every program compiled.
+type stressReadyMsg struct {
+	session *stressSession
+}
+
+// stressProgressMsg reports the seeds tested so far.
+type stressProgressMsg struct {
+	Seed   int
+	Tested int
+}
+
+// stressFoundMsg carries a counterexample: the generated input, the reference
+// answer and what the solution printed.
+type stressFoundMsg struct {
+	Seed     int
+	Inputs   []string
+	Expected []string
+	Actual   []string
+	Err      error
+}
+
+// stressDoneMsg ends a search that found nothing, or failed with Err.
+type stressDoneMsg struct {
+	Tested int
+	Err    error
+}
+
+func runStressCommand(args []string) error {
+	fs := flag.NewFlagSet("defi stress", flag.ContinueOnError)
+	bruteFlag := fs.String("brute", "", "Reference solution producing the expected answers")
+	genFlag := fs.String("gen", "", "Generator printing a test for the seed passed as its argument (default: the GEN directive)")
+	checkerFlag := fs.String("checker", "", "Output checker: exact, tokens, float[:EPS] or a checker program")
+	seedFlag := fs.Int("seed", 1, "First generator seed")
+	iterationsFlag := fs.Int("iterations", 0, "Stop after this many tests (0 runs until a mismatch)")
+	timeLimitFlag := fs.Duration("time-limit", 0, "Per-test time limit for the solution when it declares none")
+	compileFlagsFlag := fs.String("compile-flags", "", "Override compiler flags (space-separated)")
+
+	// Accept flags both before and after the solution.
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() == 0 {
+		return usageErrorf("missing solution file")
+	}
+	solution := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return usageError{err}
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *bruteFlag == "" {
+		return usageErrorf("--brute is required")
+	}
+	if *iterationsFlag < 0 {
+		return usageErrorf("iterations must not be negative")
+	}
+
+	opts := stressOptions{
+		solution:     solution,
+		brute:        *bruteFlag,
+		generator:    *genFlag,
+		checker:      *checkerFlag,
+		seed:         *seedFlag,
+		iterations:   *iterationsFlag,
+		timeLimit:    *timeLimitFlag,
+		compileFlags: strings.Fields(*compileFlagsFlag),
+	}
+	theme, err := userTheme()
+	if err != nil {
+		return err
+	}
+	return runStressUI(newStressModel(opts, theme))
+}
+
+// newStressSession compiles the solution, reference, generator and checker
+// into a temporary directory, reporting each step through send.
+func newStressSession(ctx context.Context, opts stressOptions, send func(tea.Msg)) (*stressSession, error) {
+	dir, err := os.MkdirTemp("", "defi-stress-")
+	if err != nil {
+		return nil, err
+	}
+	s := &stressSession{dir: dir}
+
+	limits, err := loadPromptLimits(opts.solution)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.Close()
+		return nil, err
+	}
+	if limits.Time == 0 {
+		limits.Time = opts.timeLimit
+	}
+	s.limits = limits.withCompileFlags(opts.compileFlags)
+
+	if opts.generator == "" {
+		if s.genSpec, err = stressGenSpec(opts); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	programs := []struct {
+		name string
+		path string
+		dest *string
+	}{
+		{"solution", opts.solution, &s.solution},
+		{"brute", opts.brute, &s.brute},
+		{"generator", opts.generator, &s.generator},
+	}
+	for i, p := range programs {
+		if p.path == "" {
+			continue
+		}
+		phase := fmt.Sprintf("🛠️ Compiling %s", filepath.Base(p.path))
+		send(phaseMsg{Name: phase, Index: i + 1, Total: len(programs) + 1})
+		if *p.dest, err = prepareProgram(ctx, p.path, dir, p.name, opts.compileFlags); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	send(phaseMsg{Name: "🧪 Preparing checker", Index: len(programs) + 1, Total: len(programs) + 1})
+	if s.checker, err = newChecker(ctx, opts.checker, dir); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// stressGenSpec returns the GEN directive of the solution, or else of the
+// brute-force reference.
+func stressGenSpec(opts stressOptions) (*genSpec, error) {
+	for _, path := range []string{opts.solution, opts.brute} {
+		spec, err := loadGenSpec(path)
+		if err != nil || spec != nil {
+			return spec, err
+		}
+	}
+	return nil, fmt.Errorf("no --gen given and no GEN directive in %s or %s", opts.solution, opts.brute)
+}
+
+// Close removes the compiled programs.
+func (s *stressSession) Close() {
+	os.RemoveAll(s.dir)
+}
+
+// search tests seeds from seed on, at most count of them when count is
+// positive. It stops at the first seed where the solution fails or disagrees
+// with the reference and returns it; found is nil when every test passed.
+func (s *stressSession) search(ctx context.Context, seed, count int, send func(tea.Msg)) (tested int, found *stressFoundMsg, err error) {
+	for ; count == 0 || tested < count; seed++ {
+		if ctx.Err() != nil {
+			return tested, nil, errRunCanceled
+		}
+
+		counterexample, err := s.test(ctx, seed)
+		if ctx.Err() != nil {
+			return tested, nil, errRunCanceled
+		}
+		if err != nil {
+			return tested, nil, err
+		}
+		tested++
+		if counterexample != nil {
+			return tested, counterexample, nil
+		}
+		send(stressProgressMsg{Seed: seed, Tested: tested})
+	}
+	return tested, nil, nil
+}
+
+// test runs one seed, returning a counterexample when the solution fails.
+func (s *stressSession) test(ctx context.Context, seed int) (*stressFoundMsg, error) {
+	inputs, err := s.generate(ctx, seed)
+	if err != nil {
+		return nil, fmt.Errorf("generator (seed %d): %w", seed, err)
+	}
+	expected, err := execProgram(ctx, s.brute, nil, inputs, PromptLimits{})
+	if err != nil {
+		return nil, fmt.Errorf("brute (seed %d): %w", seed, err)
+	}
+
+	actual, err := execProgram(ctx, s.solution, nil, inputs, s.limits)
+	if err == nil {
+		err = s.checker.Check(inputs, expected, actual)
+	}
+	if err == nil {
+		return nil, nil
+	}
+	return &stressFoundMsg{Seed: seed, Inputs: inputs, Expected: expected, Actual: actual, Err: err}, nil
+}
+
+func (s *stressSession) generate(ctx context.Context, seed int) ([]string, error) {
+	if s.genSpec != nil {
+		return s.genSpec.Generate(uint64(seed), nil)
+	}
+	return execProgram(ctx, s.generator, []string{strconv.Itoa(seed)}, nil, PromptLimits{})
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pedrohff/defi/components"
+	"github.com/pedrohff/defi/view"
+)
+
+const (
+	stressHintSearching = "ctrl+c quit"
+	stressHintFound     = "a add as case • c continue • q quit"
+	stressHintAppended  = "c continue • q quit"
+	stressHintDone      = "q quit"
+)
+
+// stressModel drives `defi stress`: it compiles the programs, searches seeds
+// in the background and shows the first counterexample it finds.
+type stressModel struct {
+	opts    stressOptions
+	session *stressSession
+	theme   components.Theme
+
+	spinner spinner.Model
+	updates <-chan tea.Msg
+	cancel  context.CancelFunc
+	start   tea.Cmd
+
+	searching bool
+	seed      int // next seed to try
+	tested    int
+	found     *stressFoundMsg
+	appended  bool
+	err       error
+	status    string
+
+	width  int
+	height int
+	ready  bool
+}
+
+func newStressModel(opts stressOptions, theme components.Theme) stressModel {
+	theme = themeOrDefault(theme)
+	m := stressModel{
+		opts:    opts,
+		theme:   theme,
+		spinner: components.NewSpinner(theme),
+		seed:    opts.seed,
+		status:  statusPreparingRun,
+	}
+	m.start = m.startSearch()
+	return m
+}
+
+func (m stressModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.start)
+}
+
+// startSearch looks for a counterexample from m.seed on, compiling the
+// programs first when there is no session yet.
+func (m *stressModel) startSearch() tea.Cmd {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.searching = true
+	m.found = nil
+	m.appended = false
+
+	session, opts, seed := m.session, m.opts, m.seed
+	count := 0
+	if opts.iterations > 0 {
+		count = opts.iterations - m.tested
+	}
+
+	return func() tea.Msg {
+		ch := make(chan tea.Msg, 16)
+		go func() {
+			defer close(ch)
+			send := func(msg tea.Msg) {
+				select {
+				case ch <- msg:
+				case <-ctx.Done():
+				}
+			}
+
+			if session == nil {
+				var err error
+				if session, err = newStressSession(ctx, opts, send); err != nil {
+					send(stressDoneMsg{Err: err})
+					return
+				}
+				send(stressReadyMsg{session: session})
+				if ctx.Err() != nil {
+					// Quit while compiling; nobody else will clean up.
+					session.Close()
+					return
+				}
+			}
+
+			tested, found, err := session.search(ctx, seed, count, send)
+			if found != nil {
+				send(*found)
+				return
+			}
+			send(stressDoneMsg{Tested: tested, Err: err})
+		}()
+		return runnerStartedMsg{ctx: ctx, ch: ch}
+	}
+}
+
+func (m stressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.ready = true
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m.quit()
+		case "a":
+			if m.found != nil && !m.appended {
+				m.appended = true
+				return m, appendCaseCmd(m.opts.solution, m.found.Inputs, m.found.Expected)
+			}
+		case "c":
+			if m.found != nil && !m.searching && (m.opts.iterations == 0 || m.tested < m.opts.iterations) {
+				m.seed = m.found.Seed + 1
+				m.status = statusRunningTests
+				return m, m.startSearch()
+			}
+		}
+		return m, nil
+
+	case caseAppendedMsg:
+		if msg.err != nil {
+			m.appended = false
+			m.status = fmt.Sprintf("Adding case failed: %s", shortenString(msg.err.Error(), 50))
+		} else {
+			m.status = fmt.Sprintf("Seed %d added as a case", m.found.Seed)
+		}
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case runnerStartedMsg:
+		if msg.ctx.Err() != nil {
+			return m, nil
+		}
+		m.updates = msg.ch
+		return m, readRunnerUpdateCmd(m.updates)
+
+	case runnerUpdateMsg:
+		if msg.ch != m.updates || msg.done {
+			return m, nil
+		}
+
+		switch v := msg.msg.(type) {
+		case phaseMsg:
+			m.status = v.Name
+		case stressReadyMsg:
+			m.session = v.session
+			m.status = statusRunningTests
+		case stressProgressMsg:
+			m.seed = v.Seed + 1
+			m.tested++
+			m.status = fmt.Sprintf("Seed %d passed", v.Seed)
+		case stressFoundMsg:
+			m.searching = false
+			m.tested++
+			m.found = &v
+			m.seed = v.Seed
+			m.status = fmt.Sprintf("Seed %d: %s", v.Seed, shortenString(v.Err.Error(), 60))
+		case stressDoneMsg:
+			m.searching = false
+			m.err = v.Err
+			if v.Err != nil {
+				m.status = shortenString(v.Err.Error(), 60)
+			} else {
+				m.status = fmt.Sprintf("No mismatch in %d tests", m.tested)
+			}
+		}
+		return m, readRunnerUpdateCmd(m.updates)
+	}
+
+	return m, nil
+}
+
+// quit stops the search and removes the compiled programs.
+func (m stressModel) quit() (tea.Model, tea.Cmd) {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if m.session != nil {
+		m.session.Close()
+	}
+	return m, tea.Quit
+}
+
+func (m stressModel) View() string {
+	if !m.ready {
+		return "🚀 Starting Défi...\n"
+	}
+
+	status := m.status
+	var testCases []view.TestCaseData
+	var opts []view.MainViewOption
+
+	if m.found != nil {
+		testCases = []view.TestCaseData{{
+			Name:             fmt.Sprintf("Seed %d", m.found.Seed),
+			Status:           components.TestCaseFinished,
+			CompileSuccess:   true,
+			AssertionSuccess: false,
+			Inputs:           m.found.Inputs,
+			ExpectedOutput:   strings.Join(m.found.Expected, "\n"),
+			ActualOutput:     strings.Join(m.found.Actual, "\n"),
+		}}
+		opts = append(opts, view.WithSelectedIndex(0))
+		if m.appended {
+			status += " • " + stressHintAppended
+		} else {
+			status += " • " + stressHintFound
+		}
+	} else {
+		spin, hint := m.spinner.View(), stressHintSearching
+		if !m.searching {
+			spin, hint = "✔", stressHintDone
+			if m.err != nil {
+				spin = "✘"
+			}
+		}
+		seed, tested := m.seed, m.tested
+		opts = append(opts, view.WithPanel(func(width, height int) string {
+			return components.StressProgress(m.theme, width, height-2, spin, tested, seed, hint)
+		}))
+	}
+
+	opts = append(opts,
+		view.WithFilename(footerFilename(m.opts.solution)),
+		view.WithLanguage(languageLabelForPath(m.opts.solution)),
+		view.WithStatus(status),
+		view.WithTheme(m.theme),
+	)
+	return view.NewMainView(m.width, m.height, testCases, opts...).Render()
+}
+
+// runStressUI runs the stress TUI and prints its outcome. It returns
+// errTestsFailed when a counterexample was found.
+func runStressUI(m stressModel) error {
+	finalModel, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return err
+	}
+
+	fm, ok := finalModel.(stressModel)
+	if !ok {
+		return errors.New("unexpected program state")
+	}
+
+	switch {
+	case fm.found != nil:
+		fmt.Printf("🚨 Counterexample at seed %d after %d tests\n", fm.found.Seed, fm.tested)
+		fmt.Fprintln(os.Stderr, fm.found.Err)
+		return errTestsFailed
+	case fm.err != nil && !errors.Is(fm.err, errRunCanceled):
+		return fm.err
+	}
+	fmt.Printf("🎉 No mismatch in %d tests\n", fm.tested)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStressSearchFindsCounterexample(t *testing.T) {
+	dir := t.TempDir()
+	opts := stressOptions{
+		solution:  writeScript(t, dir, "sol.sh", `read n; if [ "$n" -eq 3 ]; then echo 0; else echo $((n * 2)); fi`),
+		brute:     writeScript(t, dir, "brute.sh", `read n; echo $((n + n))`),
+		generator: writeScript(t, dir, "gen.sh", `echo "$1"`),
+	}
+
+	session, err := newStressSession(context.Background(), opts, func(tea.Msg) {})
+	if err != nil {
+		t.Fatalf("newStressSession: %v", err)
+	}
+	defer session.Close()
+
+	tested, found, err := session.search(context.Background(), 1, 10, func(tea.Msg) {})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if found == nil || found.Seed != 3 || tested != 3 {
+		t.Fatalf("expected a counterexample at seed 3 after 3 tests, got %+v after %d", found, tested)
+	}
+	if found.Inputs[0] != "3" || found.Expected[0] != "6" || found.Actual[0] != "0" {
+		t.Fatalf("unexpected counterexample %+v", found)
+	}
+
+	tested, found, err = session.search(context.Background(), 4, 5, func(tea.Msg) {})
+	if err != nil || found != nil || tested != 5 {
+		t.Fatalf("expected 5 passing tests, got %d, %+v, %v", tested, found, err)
+	}
+}
+
+func TestStressUsesGenDirective(t *testing.T) {
+	dir := t.TempDir()
+	solution := filepath.Join(dir, "sol.sh")
+	if err := os.WriteFile(solution, []byte("#!/bin/sh\n: '/*defiprompt\nGEN: int(1, 20)\n*/'\nread n; [ \"$n\" -gt 15 ] && echo 0 || echo $n\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	opts := stressOptions{solution: solution, brute: writeScript(t, dir, "brute.sh", `cat`)}
+
+	session, err := newStressSession(context.Background(), opts, func(tea.Msg) {})
+	if err != nil {
+		t.Fatalf("newStressSession: %v", err)
+	}
+	defer session.Close()
+
+	_, found, err := session.search(context.Background(), 1, 200, func(tea.Msg) {})
+	if err != nil || found == nil {
+		t.Fatalf("expected a counterexample, got %v", err)
+	}
+	if n, _ := strconv.Atoi(found.Inputs[0]); n <= 15 {
+		t.Fatalf("unexpected counterexample %q", found.Inputs)
+	}
+}
@@ -1,25 +1,61 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/pedrohff/defi/components"
 	"github.com/pedrohff/defi/view"
 )
 
 type runnerStartedMsg struct {
-	ch <-chan tea.Msg
+	ctx context.Context
+	ch  <-chan tea.Msg
 }
 
 type runnerUpdateMsg struct {
+	// ch identifies the run the update belongs to.
+	ch   <-chan tea.Msg
 	msg  tea.Msg
 	done bool
 }
 
+// outputAcceptedMsg reports the outcome of writing a case's actual output back
+// into the source file.
+type outputAcceptedMsg struct {
+	index int
+	err   error
+}
+
+// caseShrunkMsg carries the minimized input of a failing case.
+type caseShrunkMsg struct {
+	path   string
+	result shrinkResult
+	err    error
+}
+
+// debounceElapsedMsg fires once the debounce window of a change has passed.
+type debounceElapsedMsg struct {
+	seq int
+}
+
 type runRequestMsg struct {
-	path string
+	path   string
+	record bool
+	// cases, when set, runs only the cases at these indexes.
+	cases []int
 }
 
 // Footer status messages displayed in the UI.
@@ -33,8 +69,22 @@ const (
 	statusNoTestCases         = "No test cases found"
 	statusRunningTests        = "Running tests..."
 	statusPreparingRun        = "Preparing run..."
+	statusRecordQueued        = "Recording queued..."
+	statusRunCanceled         = "Run canceled, restarting..."
 )
 
+// fileResult keeps the outcome of the last run of a watched file.
+type fileResult struct {
+	testCases []view.TestCaseData
+	passed    int
+	total     int
+	err       error
+	ran       bool
+	// regressions counts cases that did better in the previous run.
+	regressions int
+	historyErr  error
+}
+
 type model struct {
 	cfg appConfig
 
@@ -43,11 +93,28 @@ type model struct {
 	watcherUpdates <-chan tea.Msg
 
 	runnerActive bool
+	runningPath  string
+	// runningCases is the subset of cases being run, nil for all of them.
+	runningCases []int
+	// runnerCancel aborts the in-flight run, killing its processes.
+	runnerCancel context.CancelFunc
 
 	activePath string
+	// files lists every watched source; results holds their last run.
+	files   []string
+	results map[string]*fileResult
+
+	pendingPath   string
+	hasPending    bool
+	pendingRecord bool
+	// pendingCases is the subset of cases the pending run tests, nil for all.
+	pendingCases []int
+	// queued holds further changed files waiting behind pendingPath.
+	queued []queuedRun
+	// debounceSeq identifies the latest change; older debounce timers are ignored.
+	debounceSeq int
 
-	pendingPath string
-	hasPending  bool
+	recordedCount int
 
 	watchHasFile bool
 	watcherErr   error
@@ -60,20 +127,44 @@ type model struct {
 	summaryTotal  int
 	summaryErr    error
 
-	testCases            []view.TestCaseData
-	selectedIndex        int // -1 means no selection
+	testCases     []view.TestCaseData
+	selectedIndex int // -1 means no selection
+	listOffset    int // first test case row shown in a long list
+	detailsScroll components.DetailsScroll
+	layout        view.Layout
+	// unmaximizedLayout is restored when the maximized view is toggled off.
+	unmaximizedLayout    view.Layout
 	footerStatus         string
 	footerLanguage       string
 	footerFilename       string
 	ignoreInitialWatcher bool
+
+	form *caseForm
+
+	keys keyMap
+	help help.Model
+	// showHelp shows the full key help over the body.
+	showHelp bool
+	// menu is the footer menu being shown, if any.
+	menu *footerMenu
+
+	// shrinkCancel stops the shrinker while it minimizes a case.
+	shrinkCancel context.CancelFunc
 }
 
 func newModel(cfg appConfig, initialPath string) model {
+	cfg.theme = themeOrDefault(cfg.theme)
 	m := model{
 		cfg:           cfg,
-		spinner:       components.NewSpinner(),
+		spinner:       components.NewSpinner(cfg.theme),
 		selectedIndex: -1,
+		results:       make(map[string]*fileResult),
+		layout:        cfg.layout,
+		help:          help.New(),
 	}
+	// The overrides were validated while parsing the config.
+	m.keys, _ = newKeyMap(cfg.keys)
+	m.help.Styles = components.HelpStyles(cfg.theme)
 
 	if initialPath != "" {
 		m.activePath = initialPath
@@ -116,7 +207,7 @@ func (m model) Init() tea.Cmd {
 			cmds = append(cmds, requestRunCmd(m.activePath))
 		}
 	} else {
-		cmds = append(cmds, startWatcherCmd(m.cfg.spec, m.cfg.interval))
+		cmds = append(cmds, startWatcherCmd(m.cfg.spec, m.cfg.interval, m.cfg.poll))
 		if m.activePath != "" {
 			cmds = append(cmds, requestRunCmd(m.activePath))
 		}
@@ -131,34 +222,161 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.ready = true
+		m.help.Width = msg.Width - 2
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC:
-			return m, tea.Quit
-		case tea.KeyUp:
+		if m.form != nil {
+			return m.updateForm(msg)
+		}
+		if m.showHelp {
+			return m.updateHelp(msg)
+		}
+		if m.menu != nil {
+			return m.updateMenu(msg)
+		}
+		selected := m.selectedIndex
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m.quit()
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = true
+			return m, nil
+		case key.Matches(msg, m.keys.Up):
 			if m.selectedIndex > 0 {
 				m.selectedIndex--
 			}
-		case tea.KeyDown:
+		case key.Matches(msg, m.keys.Down):
 			if m.selectedIndex < len(m.testCases)-1 {
 				m.selectedIndex++
 			}
-		case tea.KeyEsc:
+		case key.Matches(msg, m.keys.Deselect):
 			m.selectedIndex = -1
-		}
-		// Vim-style navigation
-		switch msg.String() {
-		case "k":
-			if m.selectedIndex > 0 {
-				m.selectedIndex--
+		case key.Matches(msg, m.keys.Rerun):
+			return m.rerun(nil)
+		case key.Matches(msg, m.keys.RunCase):
+			if m.selectedIndex >= 0 && m.selectedIndex < len(m.testCases) {
+				return m.rerun([]int{m.selectedIndex})
 			}
-		case "j":
-			if m.selectedIndex < len(m.testCases)-1 {
-				m.selectedIndex++
+		case key.Matches(msg, m.keys.RerunFailed):
+			return m.rerunFailed()
+		case key.Matches(msg, m.keys.Record):
+			if m.activePath != "" {
+				return m, requestRecordCmd(m.activePath)
+			}
+		case key.Matches(msg, m.keys.Accept):
+			return m.acceptSelectedOutput()
+		case key.Matches(msg, m.keys.Shrink):
+			return m.shrinkSelectedCase()
+		case key.Matches(msg, m.keys.NewCase):
+			if m.activePath != "" {
+				m.form = newCaseForm(m.cfg.theme)
+				return m, textarea.Blink
+			}
+		case key.Matches(msg, m.keys.PrevFile):
+			return m.switchFile(-1)
+		case key.Matches(msg, m.keys.NextFile):
+			return m.switchFile(1)
+		case key.Matches(msg, m.keys.PageUp):
+			m.moveSelection(-m.listPage())
+		case key.Matches(msg, m.keys.PageDown):
+			m.moveSelection(m.listPage())
+		case key.Matches(msg, m.keys.Top):
+			if len(m.testCases) > 0 {
+				m.selectedIndex = 0
+			}
+		case key.Matches(msg, m.keys.Bottom):
+			m.selectedIndex = len(m.testCases) - 1
+		case key.Matches(msg, m.keys.SwitchFocus):
+			m.detailsScroll.Focused = 1 - m.detailsScroll.Focused
+		case key.Matches(msg, m.keys.ScrollDown):
+			m.scrollDetails(1)
+		case key.Matches(msg, m.keys.ScrollUp):
+			m.scrollDetails(-1)
+		case key.Matches(msg, m.keys.HalfPageDown):
+			m.scrollDetails(detailsScrollStep)
+		case key.Matches(msg, m.keys.HalfPageUp):
+			m.scrollDetails(-detailsScrollStep)
+		case key.Matches(msg, m.keys.Layout):
+			return m.setLayout(m.layout.Next())
+		case key.Matches(msg, m.keys.Maximize):
+			if m.layout == view.LayoutMaximized {
+				m.switchLayout(m.unmaximizedLayout)
+				return m, nil
+			}
+			if m.selectedIndex < 0 && len(m.testCases) > 0 {
+				m.selectedIndex = 0
+				m.detailsScroll = components.DetailsScroll{Focused: m.detailsScroll.Focused}
+			}
+			m.unmaximizedLayout = m.layout
+			m.switchLayout(view.LayoutMaximized)
+			return m, nil
+		}
+		if m.selectedIndex != selected {
+			m.detailsScroll = components.DetailsScroll{Focused: m.detailsScroll.Focused}
+		}
+		m.scrollToSelection()
+
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+
+	case outputAcceptedMsg:
+		if msg.err != nil {
+			m.footerStatus = fmt.Sprintf("Accept failed: %s", shortenString(msg.err.Error(), 50))
+		} else {
+			m.footerStatus = fmt.Sprintf("Case %d expected output updated", msg.index+1)
+			if m.hasExternalTests() {
+				// External .out files are not watched, so re-run explicitly.
+				return m, requestRunCmd(m.activePath)
 			}
 		}
+		return m, nil
+
+	case caseShrunkMsg:
+		m.shrinkCancel = nil
+		if errors.Is(msg.err, errRunCanceled) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.footerStatus = fmt.Sprintf("Shrink failed: %s", shortenString(msg.err.Error(), 50))
+			return m, nil
+		}
+		r := msg.result
+		if res, ok := m.results[msg.path]; ok && r.Index < len(res.testCases) {
+			tc := &res.testCases[r.Index]
+			tc.Name = fmt.Sprintf("Case %d (shrunk)", r.Index+1)
+			tc.Inputs = r.Inputs
+			tc.ExpectedOutput = strings.Join(r.Expected, "\n")
+			tc.ActualOutput = strings.Join(r.Actual, "\n")
+			tc.Shrunk = true
+		}
+		m.syncTestCases()
+		m.footerStatus = fmt.Sprintf("Case %d shrunk to %d line(s) in %d runs", r.Index+1, len(r.Inputs), r.Runs)
+		return m, nil
+
+	case problemReceivedMsg:
+		if msg.Err != nil {
+			m.footerStatus = fmt.Sprintf("Problem import failed: %s", shortenString(msg.Err.Error(), 40))
+		} else {
+			m.footerStatus = fmt.Sprintf("Received %s", shortenString(msg.Name, 50))
+		}
+		return m, nil
+
+	case settingsSavedMsg:
+		if msg.err != nil {
+			m.footerStatus = fmt.Sprintf("Saving settings failed: %s", shortenString(msg.err.Error(), 50))
+		}
+		return m, nil
+
+	case caseAppendedMsg:
+		if msg.err != nil {
+			m.footerStatus = fmt.Sprintf("Adding case failed: %s", shortenString(msg.err.Error(), 50))
+			return m, nil
+		}
+		m.footerStatus = "Case added"
+		// Re-run explicitly: the watcher is off with --once and may miss
+		// Défi's own write.
+		return m, requestRunCmd(msg.path)
 
 	case spinner.TickMsg:
 		var cmd tea.Cmd
@@ -166,10 +384,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case runnerStartedMsg:
+		if msg.ctx.Err() != nil {
+			// Canceled before it got going.
+			return m, nil
+		}
 		m.runnerUpdates = msg.ch
 		return m, readRunnerUpdateCmd(m.runnerUpdates)
 
 	case runnerUpdateMsg:
+		if msg.ch != m.runnerUpdates {
+			// Left over from a canceled run.
+			return m, nil
+		}
 		if msg.done {
 			m.runnerUpdates = nil
 			return m, nil
@@ -179,16 +405,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case phaseMsg:
 			m.footerStatus = v.Name
 
+		case outputsRecordedMsg:
+			m.recordedCount = v.Count
+
+		case historyRecordedMsg:
+			res := m.resultFor(m.runningPath)
+			res.regressions, res.historyErr = v.Regressions, v.Err
+
 		case testsInitMsg:
-			m.testCases = make([]view.TestCaseData, v.Total)
-			for i := range m.testCases {
-				m.testCases[i] = view.TestCaseData{
+			res := m.resultFor(m.runningPath)
+			res.regressions, res.historyErr = 0, nil
+			pending := func(i int) view.TestCaseData {
+				return view.TestCaseData{
 					Name:             fmt.Sprintf("Case %d", i+1),
 					Status:           components.TestCasePending,
 					CompileSuccess:   false,
 					AssertionSuccess: false,
 				}
 			}
+			if v.Cases == nil {
+				// The case count changed, so every case runs.
+				m.runningCases = nil
+			}
+			if v.Cases != nil && len(res.testCases) == v.Total {
+				// Only some cases run; the others keep their results.
+				for _, i := range v.Cases {
+					if i >= 0 && i < v.Total {
+						res.testCases[i] = pending(i)
+					}
+				}
+			} else {
+				res.testCases = make([]view.TestCaseData, v.Total)
+				for i := range res.testCases {
+					res.testCases[i] = pending(i)
+				}
+			}
 			if v.Total == 0 {
 				m.footerStatus = statusNoTestCases
 			} else {
@@ -196,11 +447,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case testStatusMsg:
-			if idx := v.Current - 1; idx >= 0 && idx < len(m.testCases) {
-				tc := &m.testCases[idx]
+			res := m.resultFor(m.runningPath)
+			if idx := v.Current - 1; idx >= 0 && idx < len(res.testCases) {
+				tc := &res.testCases[idx]
 				tc.Inputs = v.Inputs
 				tc.ExpectedOutput = v.ExpectedOutput
 				tc.ActualOutput = v.ActualOutput
+				tc.Regression = v.Regression
 				switch v.Status {
 				case testStatusRunning:
 					tc.Status = components.TestCaseRunning
@@ -229,11 +482,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.summaryTotal = v.Total
 			m.summaryErr = v.Err
 			m.runnerActive = false
+			m.runnerCancel = nil
+			res := m.resultFor(m.runningPath)
+			res.passed, res.total, res.err, res.ran = v.Passed, v.Total, v.Err, true
+			partial := m.runningCases != nil && !errors.Is(v.Err, errRunCanceled)
+			if partial {
+				res.mergePartial(v.Err)
+				m.summaryPassed, m.summaryTotal, m.summaryErr = res.passed, res.total, res.err
+			}
+			m.runningCases = nil
+			m.syncTestCases()
 			if v.Err != nil {
 				m.footerStatus = shortenString(v.Err.Error(), 60)
+			} else if partial {
+				m.footerStatus = fmt.Sprintf("Re-ran %d case(s) • %d/%d passing", v.Total, res.passed, res.total)
+			} else if res.historyErr != nil {
+				m.footerStatus = shortenString(res.historyErr.Error(), 60)
+			} else if m.recordedCount > 0 {
+				m.footerStatus = fmt.Sprintf("Recorded %d output(s)", m.recordedCount)
 			} else if !m.cfg.once {
 				m.footerStatus = statusListeningForFiles
 			}
+			if res.regressions > 0 {
+				m.footerStatus = fmt.Sprintf("▲ %d regressed since last run • %s", res.regressions, m.footerStatus)
+			}
 
 			var cmds []tea.Cmd
 			if m.runnerUpdates != nil {
@@ -242,15 +514,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			if m.cfg.once {
 				cmds = append(cmds, tea.Quit)
-			} else if m.hasPending {
-				path := m.pendingPath
-				m.pendingPath = ""
-				m.hasPending = false
-				cmds = append(cmds, requestRunCmd(path))
+			} else if pending, ok := m.takePending(); ok {
+				cmds = append(cmds, pending.request())
+			} else if len(m.queued) > 0 {
+				next := m.queued[0]
+				m.queued = m.queued[1:]
+				cmds = append(cmds, next.request())
 			}
 
 			return m, tea.Batch(cmds...)
 		}
+		m.syncTestCases()
 
 		if m.runnerUpdates != nil {
 			return m, readRunnerUpdateCmd(m.runnerUpdates)
@@ -272,9 +546,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case watchEventMsg:
 			m.watchHasFile = true
 			m.watcherErr = nil
-			m.activePath = v.Path
-			m.footerLanguage = languageLabelForPath(v.Path)
-			m.footerFilename = footerFilename(v.Path)
+			m.showFile(v.Path)
 			if !m.runnerActive {
 				if m.cfg.once {
 					m.footerStatus = statusReadyToRun
@@ -287,14 +559,26 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				triggerRun = false
 				m.ignoreInitialWatcher = false
 			}
+			if triggerRun && m.runnerActive && v.Path == m.runningPath && !m.cfg.once {
+				// A newer save makes the current results stale.
+				m.cancelRun()
+			}
 			if triggerRun {
-				if m.runnerActive {
-					m.pendingPath = v.Path
-					m.hasPending = true
-				} else {
+				switch {
+				case m.runnerActive:
+					// Queued; it runs once the current run finishes.
+					m.queueRun(queuedRun{path: v.Path})
+				case m.cfg.debounce > 0:
+					// Queued; further saves within the window restart it.
+					m.queueRun(queuedRun{path: v.Path})
+					m.debounceSeq++
+					cmds = append(cmds, debounceCmd(m.cfg.debounce, m.debounceSeq))
+				default:
 					cmds = append(cmds, requestRunCmd(v.Path))
 				}
 			}
+		case watchFilesMsg:
+			m.files = v.Paths
 		case watchIdleMsg:
 			m.watchHasFile = false
 			if !m.runnerActive {
@@ -314,41 +598,594 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, readWatcherUpdateCmd(m.watcherUpdates))
 		return m, tea.Batch(cmds...)
 
+	case debounceElapsedMsg:
+		if msg.seq != m.debounceSeq || !m.hasPending || m.runnerActive {
+			return m, nil
+		}
+		pending, _ := m.takePending()
+		return m, pending.request()
+
 	case runRequestMsg:
 		if msg.path == "" {
 			return m, nil
 		}
 		if m.runnerActive {
-			if m.pendingPath != msg.path {
-				m.pendingPath = msg.path
+			m.queueRun(queuedRun{path: msg.path, record: msg.record, cases: msg.cases})
+			if msg.record {
+				m.footerStatus = statusRecordQueued
 			}
-			m.hasPending = true
 			return m, nil
 		}
 
+		// Requests waiting for this file are served by this run.
+		run := queuedRun{path: msg.path, record: msg.record, cases: msg.cases}
+		if queued, ok := m.takeQueued(msg.path); ok {
+			run = run.merge(queued)
+		}
+		if m.hasPending && m.pendingPath == msg.path {
+			pending, _ := m.takePending()
+			run = run.merge(pending)
+		}
+		casesTotal := 0
+		if res, ok := m.results[msg.path]; ok {
+			casesTotal = len(res.testCases)
+		}
+
 		m.resetForNewRun(msg.path)
-		return m, startRunnerCmd(msg.path, m.cfg.compileFlags)
+		m.runningCases = run.cases
+		opts := runOptions{
+			compileFlags: m.cfg.compileFlags,
+			record:       m.cfg.record || run.record,
+			timeLimit:    m.cfg.timeLimit,
+			checker:      m.cfg.checker,
+			history:      openHistory(m.cfg.historyDir),
+			cases:        run.cases,
+			casesTotal:   casesTotal,
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		m.runnerCancel = cancel
+		return m, startRunnerCmd(ctx, msg.path, opts)
+
+	default:
+		if m.form != nil {
+			// Cursor blink and other editor messages.
+			return m, m.form.Update(msg)
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// rerun runs the active file again, only the cases at indexes when set. A run
+// of the same file in progress is replaced.
+func (m model) rerun(cases []int) (tea.Model, tea.Cmd) {
+	if m.activePath == "" {
+		return m, nil
+	}
+	if m.runnerActive && m.runningPath == m.activePath {
+		m.cancelRun()
+	}
+	return m, func() tea.Msg {
+		return runRequestMsg{path: m.activePath, cases: cases}
+	}
+}
+
+// rerunFailed runs the failing cases of the active file again, or every case
+// when the file has not finished a run yet.
+func (m model) rerunFailed() (tea.Model, tea.Cmd) {
+	res, ok := m.results[m.activePath]
+	if !ok || !res.ran {
+		return m.rerun(nil)
+	}
+	var failed []int
+	for i, tc := range res.testCases {
+		if tc.Status == components.TestCaseFinished && !(tc.CompileSuccess && tc.AssertionSuccess) {
+			failed = append(failed, i)
+		}
+	}
+	if len(failed) == 0 {
+		m.footerStatus = "No failed cases to re-run"
+		return m, nil
+	}
+	return m.rerun(failed)
+}
+
+// hasExternalTests reports whether the active source has a tests directory.
+func (m model) hasExternalTests() bool {
+	info, err := os.Stat(externalTestsDir(m.activePath))
+	return err == nil && info.IsDir()
+}
+
+// quit stops any running tests, compiles and shrinker before exiting.
+func (m model) quit() (tea.Model, tea.Cmd) {
+	m.cancelRun()
+	m.cancelShrink()
+	return m, tea.Quit
+}
+
+// updateHelp handles keys while the help overlay is open: the help key or
+// esc closes it and every other key except quit is ignored.
+func (m model) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m.quit()
+	case key.Matches(msg, m.keys.Help, m.keys.Deselect):
+		m.showHelp = false
+	}
+	return m, nil
+}
+
+// updateMenu handles keys while a footer menu is open.
+func (m model) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m.quit()
+	case key.Matches(msg, m.keys.Up):
+		m.menu.move(-1)
+	case key.Matches(msg, m.keys.Down):
+		m.menu.move(1)
+	case msg.Type == tea.KeyEnter:
+		return m.chooseMenuItem(m.menu.selected)
+	case msg.Type == tea.KeyEsc:
+		m.menu = nil
+	}
+	return m, nil
+}
+
+// chooseMenuItem applies item i of the open menu and closes it.
+func (m model) chooseMenuItem(i int) (tea.Model, tea.Cmd) {
+	menu := m.menu
+	m.menu = nil
+	switch menu.kind {
+	case menuFiles:
+		return m.openFile(menu.paths[i])
+	case menuCompileFlags:
+		m.cfg.compileFlags = menu.flags[i]
+		updated, cmd := m.rerun(nil)
+		m = updated.(model)
+		m.footerStatus = fmt.Sprintf("Compiling with %s", menu.items[i])
+		return m, cmd
+	}
+	return m, nil
+}
+
+// mouseWheelStep is how many lines the wheel scrolls the details.
+const mouseWheelStep = 3
+
+// updateMouse selects cases, scrolls the list and the details, and opens the
+// footer menus.
+func (m model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Action != tea.MouseActionPress || m.form != nil || !m.ready {
+		return m, nil
+	}
+	wheel := 0
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		wheel = -1
+	case tea.MouseButtonWheelDown:
+		wheel = 1
+	case tea.MouseButtonLeft:
+	default:
+		return m, nil
+	}
+	hit := m.mainView().HitTest(msg.X, msg.Y)
+
+	switch {
+	case m.menu != nil:
+		if wheel != 0 {
+			m.menu.move(wheel)
+			return m, nil
+		}
+		if hit.Area == view.HitOverlay {
+			if i := components.MenuItemAt(len(m.menu.items), hit.Y); i >= 0 {
+				return m.chooseMenuItem(i)
+			}
+			return m, nil
+		}
+		// A click outside closes the menu.
+		m.menu = nil
+		return m, nil
+	case m.showHelp:
+		if wheel == 0 {
+			m.showHelp = false
+		}
+		return m, nil
+	}
+
+	selected := m.selectedIndex
+	switch hit.Area {
+	case view.HitCase:
+		if wheel != 0 {
+			m.moveSelection(wheel)
+		} else {
+			m.selectedIndex = hit.Index
+		}
+	case view.HitDetails:
+		if hit.Section >= 0 {
+			m.detailsScroll.Focused = hit.Section
+			m.scrollDetails(wheel * mouseWheelStep)
+		}
+	case view.HitLanguage:
+		if wheel == 0 && m.activePath != "" {
+			m.menu = newCompileFlagsMenu(m.cfg.theme, filepath.Ext(m.activePath), m.cfg.compileFlags)
+		}
+	case view.HitFilename:
+		files := m.files
+		if len(files) == 0 && m.activePath != "" {
+			files = []string{m.activePath}
+		}
+		if wheel == 0 && len(files) > 0 {
+			m.menu = newFileMenu(m.cfg.theme, files, m.activePath)
+		}
+	}
+	if m.selectedIndex != selected {
+		m.detailsScroll = components.DetailsScroll{Focused: m.detailsScroll.Focused}
+	}
+	m.scrollToSelection()
+	return m, nil
+}
+
+// updateForm handles key presses while the new case form is open.
+func (m model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m.quit()
+	case tea.KeyEsc:
+		m.form = nil
+		return m, nil
+	case tea.KeyCtrlS:
+		inputs, outputs := m.form.Lines()
+		if len(inputs) == 0 {
+			m.footerStatus = "A case needs at least one input line"
+			return m, nil
+		}
+		m.form = nil
+		return m, appendCaseCmd(m.activePath, inputs, outputs)
+	}
+	return m, m.form.Update(msg)
+}
+
+// shrinkSelectedCase minimizes the input of the selected failing case in the
+// background; the result replaces the case in the details pane.
+func (m model) shrinkSelectedCase() (tea.Model, tea.Cmd) {
+	if m.activePath == "" || m.selectedIndex < 0 || m.selectedIndex >= len(m.testCases) {
+		return m, nil
 	}
+	if m.shrinkCancel != nil {
+		m.footerStatus = "Already shrinking a case"
+		return m, nil
+	}
+
+	tc := m.testCases[m.selectedIndex]
+	if tc.Status != components.TestCaseFinished || (tc.CompileSuccess && tc.AssertionSuccess) {
+		m.footerStatus = "Only failing cases can be shrunk"
+		return m, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.shrinkCancel = cancel
+	m.footerStatus = fmt.Sprintf("Shrinking case %d...", m.selectedIndex+1)
+	opts := shrinkOptions{
+		solution:     m.activePath,
+		reference:    m.cfg.reference,
+		validator:    m.cfg.validator,
+		checker:      m.cfg.checker,
+		timeLimit:    m.cfg.timeLimit,
+		compileFlags: m.cfg.compileFlags,
+	}
+	return m, shrinkCaseCmd(ctx, opts, m.selectedIndex)
+}
+
+// cancelShrink stops a running shrinker.
+func (m *model) cancelShrink() {
+	if m.shrinkCancel != nil {
+		m.shrinkCancel()
+		m.shrinkCancel = nil
+	}
+}
+
+// acceptSelectedOutput writes the selected case's actual output over its
+// expected output, letting the watcher pick up the change and re-run.
+func (m model) acceptSelectedOutput() (tea.Model, tea.Cmd) {
+	if m.activePath == "" || m.selectedIndex < 0 || m.selectedIndex >= len(m.testCases) {
+		return m, nil
+	}
+
+	tc := m.testCases[m.selectedIndex]
+	if tc.Status != components.TestCaseFinished || !tc.CompileSuccess {
+		m.footerStatus = "Nothing to accept for this case"
+		return m, nil
+	}
+	if tc.Shrunk {
+		// The output belongs to the shrunk input, not to the case in the file.
+		m.footerStatus = "Shrunk cases cannot be accepted"
+		return m, nil
+	}
+	if tc.ActualOutput == "" {
+		m.footerStatus = "Case produced no output to accept"
+		return m, nil
+	}
+
+	return m, acceptOutputCmd(m.activePath, m.selectedIndex, strings.Split(tc.ActualOutput, "\n"))
+}
+
+// resultFor returns the stored results of path, creating them if needed.
+func (m *model) resultFor(path string) *fileResult {
+	res, ok := m.results[path]
+	if !ok {
+		res = &fileResult{}
+		m.results[path] = res
+	}
+	return res
+}
+
+// mergePartial updates the totals after a run of some cases from all of the
+// file's cases, the ones that ran and the ones kept from earlier runs. err is
+// the error of the cases that ran.
+func (r *fileResult) mergePartial(err error) {
+	r.passed, r.total, r.regressions = 0, len(r.testCases), 0
+	for _, tc := range r.testCases {
+		if tc.Status == components.TestCaseFinished && tc.CompileSuccess && tc.AssertionSuccess {
+			r.passed++
+		}
+		if tc.Regression != "" {
+			r.regressions++
+		}
+	}
+	r.err = err
+	if r.err == nil && r.passed < r.total {
+		r.err = fmt.Errorf("%d of %d cases failing", r.total-r.passed, r.total)
+	}
+}
+
+// syncTestCases points the displayed test list at the active file's results.
+func (m *model) syncTestCases() {
+	m.testCases = nil
+	if res, ok := m.results[m.activePath]; ok {
+		m.testCases = res.testCases
+	}
+	if m.selectedIndex >= len(m.testCases) {
+		m.selectedIndex = len(m.testCases) - 1
+	}
+}
+
+// helpOverlay renders the full key help within width cells, stacking the
+// groups in two rows when they do not fit side by side.
+func (m model) helpOverlay(width, height int) string {
+	m.help.Width = 0
+	groups := m.keys.FullHelp()
+	keys := m.help.FullHelpView(groups)
+	// Leave room for the overlay's border and padding.
+	if lipgloss.Width(keys) > width-8 {
+		half := (len(groups) + 1) / 2
+		keys = lipgloss.JoinVertical(lipgloss.Left,
+			m.help.FullHelpView(groups[:half]),
+			"",
+			m.help.FullHelpView(groups[half:]),
+		)
+	}
+	return components.HelpOverlay(m.cfg.theme, keys, "? or esc to close")
+}
+
+// viewHeight is the height left for the main view once the help bar is
+// taken out.
+func (m model) viewHeight() int {
+	return m.height - view.HelpBarHeight
+}
+
+// listPage is how many test case rows are visible at once.
+func (m model) listPage() int {
+	details := m.form != nil || m.selectedIndex >= 0
+	return max(view.ListRows(m.layout, m.viewHeight(), details, len(m.testCases)), 1)
+}
+
+// moveSelection moves the selected case by delta rows, stopping at either
+// end of the list. Without a selection it starts from the top.
+func (m *model) moveSelection(delta int) {
+	if len(m.testCases) == 0 {
+		return
+	}
+	idx := max(m.selectedIndex, 0)
+	if m.selectedIndex >= 0 {
+		idx += delta
+	}
+	m.selectedIndex = max(min(idx, len(m.testCases)-1), 0)
+}
 
+// scrollToSelection scrolls the test list so the selected case is visible.
+func (m *model) scrollToSelection() {
+	m.listOffset = view.ScrollOffset(m.listOffset, m.selectedIndex, m.listPage(), len(m.testCases))
+}
+
+// detailsScrollStep is how many lines ctrl+d and ctrl+u scroll the details.
+const detailsScrollStep = 10
+
+// scrollDetails scrolls the focused viewport of the selected case's details
+// by delta lines.
+func (m *model) scrollDetails(delta int) {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.testCases) {
+		return
+	}
+	maxInputs, maxOutputs := view.DetailsScrollLimits(m.layout, m.viewHeight(), len(m.testCases), m.testCases[m.selectedIndex])
+	if m.detailsScroll.Focused == 0 {
+		m.detailsScroll.Inputs = max(min(m.detailsScroll.Inputs+delta, maxInputs), 0)
+	} else {
+		m.detailsScroll.Outputs = max(min(m.detailsScroll.Outputs+delta, maxOutputs), 0)
+	}
+}
+
+// setLayout switches the layout and remembers it in the user config.
+func (m model) setLayout(layout view.Layout) (tea.Model, tea.Cmd) {
+	m.switchLayout(layout)
+	return m, saveUserSettingCmd(m.cfg.settingsPath, "layout", string(m.layout))
+}
+
+// switchLayout switches the layout for this session only, as the maximize
+// toggle does.
+func (m *model) switchLayout(layout view.Layout) {
+	if layout == "" {
+		layout = view.LayoutSplitHorizontal
+	}
+	m.layout = layout
+	m.scrollToSelection()
+	m.detailsScroll = components.DetailsScroll{Focused: m.detailsScroll.Focused}
+	m.footerStatus = fmt.Sprintf("Layout: %s", layout)
+}
+
+// showFile makes path the file displayed in the test list and footer.
+func (m *model) showFile(path string) {
+	if path != m.activePath {
+		m.selectedIndex = -1
+		m.listOffset = 0
+		m.detailsScroll = components.DetailsScroll{}
+	}
+	m.activePath = path
+	m.footerLanguage = languageLabelForPath(path)
+	m.footerFilename = footerFilename(path)
+	m.syncTestCases()
+}
+
+// switchFile moves the display to the previous or next watched file, running
+// it when it has no results yet.
+func (m model) switchFile(delta int) (tea.Model, tea.Cmd) {
+	if len(m.files) < 2 {
+		return m, nil
+	}
+
+	current := slices.Index(m.files, m.activePath)
+	next := (current + delta + len(m.files)) % len(m.files)
+	if current == -1 {
+		next = 0
+	}
+	return m.openFile(m.files[next])
+}
+
+// openFile shows path, running it when it has no results yet.
+func (m model) openFile(path string) (tea.Model, tea.Cmd) {
+	m.showFile(path)
+	if res, ok := m.results[m.activePath]; (!ok || !res.ran) && m.runningPath != m.activePath {
+		return m, requestRunCmd(m.activePath)
+	}
 	return m, nil
 }
 
+// queuedRun is a file waiting for its turn to run.
+type queuedRun struct {
+	path string
+	// record asks the run to record the outputs of pending cases.
+	record bool
+	// cases, when set, runs only the cases at these indexes.
+	cases []int
+}
+
+// merge combines two requests for the same file. A run of every case covers
+// any subset.
+func (q queuedRun) merge(other queuedRun) queuedRun {
+	q.record = q.record || other.record
+	if q.cases == nil || other.cases == nil {
+		q.cases = nil
+	} else {
+		cases := append(slices.Clone(q.cases), other.cases...)
+		slices.Sort(cases)
+		q.cases = slices.Compact(cases)
+	}
+	return q
+}
+
+// request asks for the run.
+func (q queuedRun) request() tea.Cmd {
+	return func() tea.Msg {
+		return runRequestMsg{path: q.path, record: q.record, cases: q.cases}
+	}
+}
+
+// queueRun marks q to run next, keeping a different, already pending file in
+// line behind it. Record requests and case subsets stay with their file.
+func (m *model) queueRun(q queuedRun) {
+	if pending, ok := m.takePending(); ok {
+		if pending.path == q.path {
+			q = q.merge(pending)
+		} else {
+			m.enqueue(pending)
+		}
+	}
+	if queued, ok := m.takeQueued(q.path); ok {
+		q = q.merge(queued)
+	}
+	m.pendingPath, m.pendingRecord, m.pendingCases = q.path, q.record, q.cases
+	m.hasPending = true
+}
+
+// takePending clears the pending run and returns it.
+func (m *model) takePending() (queuedRun, bool) {
+	if !m.hasPending {
+		return queuedRun{}, false
+	}
+	q := queuedRun{path: m.pendingPath, record: m.pendingRecord, cases: m.pendingCases}
+	m.pendingPath, m.pendingRecord, m.pendingCases = "", false, nil
+	m.hasPending = false
+	return q, true
+}
+
+// enqueue puts q at the end of the line, or merges it into the entry of the
+// same file already there.
+func (m *model) enqueue(q queuedRun) {
+	if i := slices.IndexFunc(m.queued, func(e queuedRun) bool { return e.path == q.path }); i >= 0 {
+		m.queued[i] = m.queued[i].merge(q)
+		return
+	}
+	m.queued = append(m.queued, q)
+}
+
+// takeQueued removes path from the line, returning its entry.
+func (m *model) takeQueued(path string) (queuedRun, bool) {
+	i := slices.IndexFunc(m.queued, func(e queuedRun) bool { return e.path == path })
+	if i < 0 {
+		return queuedRun{}, false
+	}
+	q := m.queued[i]
+	m.queued = slices.Delete(m.queued, i, i+1)
+	return q, true
+}
+
+// cancelRun aborts the in-flight run; updates it still sends are ignored.
+func (m *model) cancelRun() {
+	if !m.runnerActive {
+		return
+	}
+	if m.runnerCancel != nil {
+		m.runnerCancel()
+		m.runnerCancel = nil
+	}
+	m.runnerActive = false
+	m.runnerUpdates = nil
+	m.footerStatus = statusRunCanceled
+}
+
 // resetForNewRun clears all test state and prepares the model for a fresh run.
 func (m *model) resetForNewRun(path string) {
 	// Runner state
 	m.runnerActive = true
-	m.hasPending = false
-	m.pendingPath = ""
+	if pending, ok := m.takePending(); ok && pending.path != path {
+		// Another file waiting for the debounce runs after this one.
+		m.enqueue(pending)
+	}
 
 	// Previous results
+	m.recordedCount = 0
 	m.summaryErr = nil
 	m.summaryPassed = 0
 	m.summaryTotal = 0
 
+	// A shrink in progress judges the previous version of the source.
+	if path == m.activePath {
+		m.cancelShrink()
+	}
+
 	// File info
-	m.activePath = path
-	m.footerLanguage = languageLabelForPath(path)
-	m.footerFilename = footerFilename(path)
+	m.runningPath = path
+	m.showFile(path)
 	m.footerStatus = statusPreparingRun
 	m.watchHasFile = true
 }
@@ -359,22 +1196,69 @@ func (m model) View() string {
 	}
 
 	if m.watcherErr != nil {
-		return components.RenderError(m.watcherErr.Error())
+		return components.RenderError(m.cfg.theme, m.watcherErr.Error())
 	}
 
+	return m.mainView().Render()
+}
+
+// mainView describes the screen; View renders it and mouse events are
+// hit-tested against it.
+func (m model) mainView() *view.MainView {
 	statusText := m.footerStatus
 	if statusText == "" {
 		statusText = statusIdle
 	}
 
-	mainView := view.NewMainView(m.width, m.height, m.testCases,
+	opts := []view.MainViewOption{
+		view.WithFiles(m.fileSummaries()),
 		view.WithSelectedIndex(m.selectedIndex),
+		view.WithListOffset(m.listOffset),
+		view.WithDetailsScroll(m.detailsScroll),
+		view.WithLayout(m.layout),
 		view.WithFilename(m.footerFilename),
 		view.WithLanguage(m.footerLanguage),
 		view.WithStatus(statusText),
-	)
+		view.WithTheme(m.cfg.theme),
+	}
+	if m.form != nil {
+		opts = append(opts, view.WithPanel(m.form.View))
+	}
+	switch {
+	case m.showHelp:
+		opts = append(opts, view.WithOverlay(m.helpOverlay))
+	case m.menu != nil:
+		opts = append(opts, view.WithOverlay(m.menu.View))
+	}
+	if !m.showHelp {
+		opts = append(opts, view.WithHelp(m.help.ShortHelpView(m.keys.ShortHelp())))
+	}
+
+	return view.NewMainView(m.width, m.height, m.testCases, opts...)
+}
 
-	return mainView.Render()
+// fileSummaries describes every watched file for the sidebar.
+func (m model) fileSummaries() []view.FileData {
+	files := make([]view.FileData, len(m.files))
+	for i, path := range m.files {
+		f := view.FileData{
+			Name:   footerFilename(path),
+			Status: components.TestCasePending,
+			Active: path == m.activePath,
+		}
+		if res, ok := m.results[path]; ok && res.ran {
+			f.Passed, f.Total = res.passed, res.total
+			f.Status = components.TestCaseBlockStatusPass
+			if res.err != nil {
+				f.Status = components.TestCaseBlockStatusFail
+			}
+		}
+		if m.runnerActive && path == m.runningPath {
+			f.Status = components.TestCaseRunning
+		}
+		files[i] = f
+	}
+	return files
 }
 
 func requestRunCmd(path string) tea.Cmd {
@@ -383,17 +1267,70 @@ func requestRunCmd(path string) tea.Cmd {
 	}
 }
 
-func startRunnerCmd(sourcePath string, compileFlags []string) tea.Cmd {
+// requestRecordCmd asks for a run that records the output of pending cases.
+func requestRecordCmd(path string) tea.Cmd {
+	return runRequestCmd(path, true)
+}
+
+func runRequestCmd(path string, record bool) tea.Cmd {
+	return func() tea.Msg {
+		return runRequestMsg{path: path, record: record}
+	}
+}
+
+// debounceCmd reports when the debounce window for change seq has elapsed.
+func debounceCmd(window time.Duration, seq int) tea.Cmd {
+	return tea.Tick(window, func(time.Time) tea.Msg {
+		return debounceElapsedMsg{seq: seq}
+	})
+}
+
+// acceptOutputCmd rewrites the OUTPUT section of a single case with outputs.
+func acceptOutputCmd(path string, index int, outputs []string) tea.Cmd {
+	return func() tea.Msg {
+		err := writeCaseOutputs(path, map[int][]string{index: outputs})
+		return outputAcceptedMsg{index: index, err: err}
+	}
+}
+
+// shrinkCaseCmd minimizes case idx of opts.solution as currently saved.
+func shrinkCaseCmd(ctx context.Context, opts shrinkOptions, idx int) tea.Cmd {
+	return func() tea.Msg {
+		cases, err := loadTestSuite(opts.solution)
+		if err != nil {
+			return caseShrunkMsg{path: opts.solution, err: err}
+		}
+		if idx >= len(cases) {
+			return caseShrunkMsg{path: opts.solution, err: fmt.Errorf("case %d not found", idx+1)}
+		}
+
+		s, err := newShrinker(ctx, opts)
+		if err != nil {
+			return caseShrunkMsg{path: opts.solution, err: err}
+		}
+		defer s.Close()
+
+		result, err := s.shrink(ctx, idx, cases[idx])
+		return caseShrunkMsg{path: opts.solution, result: result, err: err}
+	}
+}
+
+func startRunnerCmd(ctx context.Context, sourcePath string, opts runOptions) tea.Cmd {
 	return func() tea.Msg {
 		ch := make(chan tea.Msg, 16)
 		go func() {
-			passed, total, err := runWorkflow(sourcePath, compileFlags, func(msg tea.Msg) {
-				ch <- msg
-			})
-			ch <- testsDoneMsg{Passed: passed, Total: total, Err: err}
+			// Once canceled nobody reads the channel, so updates are dropped.
+			send := func(msg tea.Msg) {
+				select {
+				case ch <- msg:
+				case <-ctx.Done():
+				}
+			}
+			passed, total, err := runWorkflow(ctx, sourcePath, opts, send)
+			send(testsDoneMsg{Passed: passed, Total: total, Err: err})
 			close(ch)
 		}()
-		return runnerStartedMsg{ch: ch}
+		return runnerStartedMsg{ctx: ctx, ch: ch}
 	}
 }
 
@@ -401,8 +1338,8 @@ func readRunnerUpdateCmd(ch <-chan tea.Msg) tea.Cmd {
 	return func() tea.Msg {
 		msg, ok := <-ch
 		if !ok {
-			return runnerUpdateMsg{done: true}
+			return runnerUpdateMsg{ch: ch, done: true}
 		}
-		return runnerUpdateMsg{msg: msg}
+		return runnerUpdateMsg{ch: ch, msg: msg}
 	}
 }
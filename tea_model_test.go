@@ -0,0 +1,484 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/pedrohff/defi/components"
+	"github.com/pedrohff/defi/view"
+)
+
+func TestWatchEventsCoalesceWithinDebounce(t *testing.T) {
+	m := newModel(appConfig{debounce: 50 * time.Millisecond}, "")
+
+	var seq int
+	for i := 0; i < 3; i++ {
+		updated, _ := m.Update(watcherUpdateMsg{msg: watchEventMsg{Path: "a.cpp"}})
+		m = updated.(model)
+		seq = m.debounceSeq
+	}
+
+	if !m.hasPending || m.pendingPath != "a.cpp" || m.runnerActive {
+		t.Fatalf("expected a single queued run, got pending=%v path=%q", m.hasPending, m.pendingPath)
+	}
+
+	updated, cmd := m.Update(debounceElapsedMsg{seq: seq - 1})
+	if cmd != nil || !updated.(model).hasPending {
+		t.Fatalf("stale debounce timer must not start a run")
+	}
+
+	updated, cmd = m.Update(debounceElapsedMsg{seq: seq})
+	if cmd == nil || updated.(model).hasPending {
+		t.Fatalf("expected the latest debounce timer to start the queued run")
+	}
+	if msg, ok := cmd().(runRequestMsg); !ok || msg.path != "a.cpp" {
+		t.Fatalf("expected a run request for a.cpp, got %#v", msg)
+	}
+}
+
+func TestQueuedRecordRequestSurvivesOtherSaves(t *testing.T) {
+	m := newModel(appConfig{}, "")
+	m.resetForNewRun("a.cpp")
+
+	updated, _ := m.Update(runRequestMsg{path: "b.cpp", record: true})
+	m = updated.(model)
+	updated, _ = m.Update(watcherUpdateMsg{msg: watchEventMsg{Path: "c.cpp"}})
+	m = updated.(model)
+	if m.pendingPath != "c.cpp" || m.pendingRecord {
+		t.Fatalf("expected c.cpp to run next without recording, got %q record=%v", m.pendingPath, m.pendingRecord)
+	}
+	if len(m.queued) != 1 || m.queued[0].path != "b.cpp" || !m.queued[0].record {
+		t.Fatalf("expected b.cpp to keep its record request in the queue, got %+v", m.queued)
+	}
+
+	// Saving b.cpp again brings it to the front with its request.
+	updated, _ = m.Update(watcherUpdateMsg{msg: watchEventMsg{Path: "b.cpp"}})
+	m = updated.(model)
+	if m.pendingPath != "b.cpp" || !m.pendingRecord || len(m.queued) != 1 || m.queued[0].path != "c.cpp" {
+		t.Fatalf("expected b.cpp pending with its record request, got %q record=%v queue=%+v", m.pendingPath, m.pendingRecord, m.queued)
+	}
+}
+
+func TestResultsAreKeptPerFile(t *testing.T) {
+	m := newModel(appConfig{}, "")
+	m.files = []string{"a.cpp", "b.cpp"}
+
+	m.resetForNewRun("a.cpp")
+	updated, _ := m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 2}})
+	m = updated.(model)
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsDoneMsg{Passed: 2, Total: 2}})
+	m = updated.(model)
+
+	updated, cmd := m.Update(keyMsg("]"))
+	m = updated.(model)
+	if m.activePath != "b.cpp" || len(m.testCases) != 0 {
+		t.Fatalf("expected b.cpp with no results, got %q with %d cases", m.activePath, len(m.testCases))
+	}
+	if cmd == nil {
+		t.Fatalf("expected switching to a file that never ran to request a run")
+	}
+	if msg, ok := cmd().(runRequestMsg); !ok || msg.path != "b.cpp" {
+		t.Fatalf("expected a run request for b.cpp, got %#v", msg)
+	}
+
+	updated, _ = m.Update(keyMsg("["))
+	m = updated.(model)
+	if m.activePath != "a.cpp" || len(m.testCases) != 2 {
+		t.Fatalf("expected a.cpp results to be restored, got %q with %d cases", m.activePath, len(m.testCases))
+	}
+	if files := m.fileSummaries(); files[0].Passed != 2 || !files[0].Active {
+		t.Fatalf("unexpected sidebar entry %#v", files[0])
+	}
+}
+
+func keyMsg(key string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func TestNewerSaveCancelsRunningWorkflow(t *testing.T) {
+	m := newModel(appConfig{}, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stale := make(chan tea.Msg)
+
+	m.resetForNewRun("a.cpp")
+	m.runnerCancel = cancel
+	m.runnerUpdates = stale
+
+	updated, cmd := m.Update(watcherUpdateMsg{msg: watchEventMsg{Path: "a.cpp"}})
+	m = updated.(model)
+	if ctx.Err() == nil || m.runnerActive {
+		t.Fatalf("expected the running workflow to be canceled")
+	}
+	if cmd == nil {
+		t.Fatalf("expected a new run to be requested")
+	}
+
+	updated, _ = m.Update(runnerUpdateMsg{ch: stale, msg: testsInitMsg{Total: 3}})
+	if len(updated.(model).testCases) != 0 {
+		t.Fatalf("updates from a canceled run must be ignored")
+	}
+}
+
+func TestQuitFromTheCaseFormCancelsTheRun(t *testing.T) {
+	m := newModel(appConfig{}, "a.cpp")
+	updated, _ := m.Update(runRequestMsg{path: "a.cpp"})
+	m = updated.(model)
+	canceled := false
+	m.runnerCancel = func() { canceled = true }
+	updated, _ = m.Update(keyMsg("n"))
+	if m = updated.(model); m.form == nil {
+		t.Fatal("expected n to open the case form")
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if _, ok := cmd().(tea.QuitMsg); !ok || !canceled || updated.(model).runnerActive {
+		t.Fatalf("expected ctrl+c to cancel the run and quit, got %#v", cmd())
+	}
+}
+
+func TestAppendedCaseReruns(t *testing.T) {
+	m := newModel(appConfig{once: true}, "a.cpp")
+	updated, cmd := m.Update(caseAppendedMsg{path: "a.cpp"})
+	if req, ok := cmd().(runRequestMsg); !ok || req.path != "a.cpp" || updated.(model).footerStatus != "Case added" {
+		t.Fatalf("expected the appended case to trigger a run, got %#v", req)
+	}
+}
+
+func TestWorkflowStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := runWorkflow(ctx, "missing.cpp", runOptions{}, func(tea.Msg) {})
+	if !errors.Is(err, errRunCanceled) {
+		t.Fatalf("expected errRunCanceled, got %v", err)
+	}
+}
+
+func TestWorkflowBuildsOutsideTheWorkingDirectory(t *testing.T) {
+	if _, err := exec.LookPath("g++"); err != nil {
+		t.Skip("g++ not available")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	source := "#include <iostream>\nint main() { int n; std::cin >> n; std::cout << n * 2 << std::endl; }\n/*defiprompt\nINPUTS:\n3\nOUTPUT:\n6\n*/\n"
+	if err := os.WriteFile("a.cpp", []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	passed, total, err := runWorkflow(context.Background(), "a.cpp", runOptions{}, func(tea.Msg) {})
+	if err != nil || passed != 1 || total != 1 {
+		t.Fatalf("expected 1/1 passing, got %d/%d, %v", passed, total, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expected the build to stay out of the working directory, got %v", entries)
+	}
+}
+
+func TestLongListScrollsWithSelection(t *testing.T) {
+	m := newModel(appConfig{}, "")
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updated.(model)
+	m.resetForNewRun("a.cpp")
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 60}})
+	m = updated.(model)
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnd})
+	m = updated.(model)
+	page := m.listPage()
+	if m.selectedIndex != 59 || m.listOffset != 60-page {
+		t.Fatalf("expected the last case at the bottom, got selection %d offset %d (page %d)", m.selectedIndex, m.listOffset, page)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	m = updated.(model)
+	if m.selectedIndex != 59-page || m.listOffset != m.selectedIndex {
+		t.Fatalf("expected a page up to scroll with the selection, got selection %d offset %d", m.selectedIndex, m.listOffset)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyHome})
+	m = updated.(model)
+	if m.selectedIndex != 0 || m.listOffset != 0 {
+		t.Fatalf("expected home to go back to the top, got selection %d offset %d", m.selectedIndex, m.listOffset)
+	}
+
+	out := m.View()
+	if lines := strings.Count(out, "\n") + 1; lines > 30 {
+		t.Fatalf("expected the view to fit 30 lines, got %d", lines)
+	}
+	if !strings.Contains(out, fmt.Sprintf("1-%d of 60", page)) {
+		t.Fatalf("expected a position indicator in:\n%s", out)
+	}
+}
+
+func TestDetailsScrollIsClampedAndResetOnSelection(t *testing.T) {
+	m := newModel(appConfig{}, "")
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(model)
+	m.resetForNewRun("a.cpp")
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 2}})
+	m = updated.(model)
+
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines = append(lines, fmt.Sprint(i))
+	}
+	output := strings.Join(lines, "\n")
+	updated, _ = m.Update(runnerUpdateMsg{msg: testStatusMsg{Current: 1, Total: 2, Status: testStatusPassed, Inputs: []string{"1"}, ExpectedOutput: output, ActualOutput: output}})
+	m = updated.(model)
+
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyHome}, {Type: tea.KeyTab}, {Type: tea.KeyCtrlD}, {Type: tea.KeyCtrlD}, {Type: tea.KeyCtrlD}, {Type: tea.KeyCtrlD}, {Type: tea.KeyCtrlD}} {
+		updated, _ = m.Update(key)
+		m = updated.(model)
+	}
+	_, maxOutputs := view.DetailsScrollLimits(m.layout, m.height, len(m.testCases), m.testCases[0])
+	if m.detailsScroll.Focused != 1 || m.detailsScroll.Outputs != maxOutputs || m.detailsScroll.Inputs != 0 {
+		t.Fatalf("expected the outputs scrolled to %d, got %+v", maxOutputs, m.detailsScroll)
+	}
+
+	updated, _ = m.Update(keyMsg("j"))
+	m = updated.(model)
+	if m.selectedIndex != 1 || m.detailsScroll.Outputs != 0 || m.detailsScroll.Focused != 1 {
+		t.Fatalf("expected selecting another case to reset the scroll, got %+v", m.detailsScroll)
+	}
+}
+
+func TestLayoutsFitTheScreenAndArePersisted(t *testing.T) {
+	settings := filepath.Join(t.TempDir(), "config.json")
+	m := newModel(appConfig{settingsPath: settings}, "")
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = updated.(model)
+	m.resetForNewRun("a.cpp")
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 40}})
+	m = updated.(model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnd})
+	m = updated.(model)
+
+	for _, want := range []view.Layout{view.LayoutSplitVertical, view.LayoutListOnly, view.LayoutMaximized, view.LayoutSplitHorizontal} {
+		updated, cmd := m.Update(keyMsg("l"))
+		m = updated.(model)
+		if m.layout != want {
+			t.Fatalf("expected layout %s, got %s", want, m.layout)
+		}
+		if msg, ok := cmd().(settingsSavedMsg); !ok || msg.err != nil {
+			t.Fatalf("expected the layout to be saved, got %#v", msg)
+		}
+		if saved, err := loadUserSettings(settings); err != nil || saved.Layout != string(want) {
+			t.Fatalf("expected %s in the config, got %+v, %v", want, saved, err)
+		}
+
+		out := m.View()
+		if lines := strings.Count(out, "\n") + 1; lines != 30 {
+			t.Fatalf("%s: expected 30 lines, got %d:\n%s", want, lines, out)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if w := lipgloss.Width(line); w > 120 {
+				t.Fatalf("%s: line is %d cells wide:\n%s", want, w, out)
+			}
+		}
+		if want != view.LayoutMaximized && !strings.Contains(out, "Case 40") {
+			t.Fatalf("%s: expected the selected case to stay visible:\n%s", want, out)
+		}
+	}
+
+	updated, cmd := m.Update(keyMsg("z"))
+	m = updated.(model)
+	if m.layout != view.LayoutMaximized || strings.Contains(m.View(), "TEST CASE") {
+		t.Fatalf("expected z to show the details alone, got %s", m.layout)
+	}
+	if cmd != nil {
+		t.Fatalf("expected z not to save the layout, got %#v", cmd())
+	}
+	if saved, err := loadUserSettings(settings); err != nil || saved.Layout != string(view.LayoutSplitHorizontal) {
+		t.Fatalf("expected the cycled layout to stay in the config, got %+v, %v", saved, err)
+	}
+	updated, cmd = m.Update(keyMsg("z"))
+	if m = updated.(model); m.layout != view.LayoutSplitHorizontal || cmd != nil {
+		t.Fatalf("expected z to restore the previous layout without saving, got %s", m.layout)
+	}
+}
+
+func TestRerunFailedMergesPartialResults(t *testing.T) {
+	m := newModel(appConfig{}, "a.cpp")
+	m.resetForNewRun("a.cpp")
+	finish := func(m model, idx int, passed bool) model {
+		status := testStatusFailed
+		if passed {
+			status = testStatusPassed
+		}
+		updated, _ := m.Update(runnerUpdateMsg{msg: testStatusMsg{Current: idx + 1, Total: 3, Status: status, CompileSuccess: true, AssertionSuccess: passed}})
+		return updated.(model)
+	}
+
+	updated, _ := m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 3}})
+	m = updated.(model)
+	m = finish(m, 0, true)
+	m = finish(m, 1, false)
+	m = finish(m, 2, false)
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsDoneMsg{Passed: 1, Total: 3, Err: errors.New("case 2 failed")}})
+	m = updated.(model)
+
+	updated, cmd := m.Update(keyMsg("f"))
+	m = updated.(model)
+	req, ok := cmd().(runRequestMsg)
+	if !ok || req.path != "a.cpp" || fmt.Sprint(req.cases) != "[1 2]" {
+		t.Fatalf("expected a run of the failed cases, got %#v", req)
+	}
+
+	updated, _ = m.Update(req)
+	m = updated.(model)
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 3, Cases: req.cases}})
+	m = updated.(model)
+	if m.testCases[0].Status != components.TestCaseFinished || m.testCases[1].Status != components.TestCasePending {
+		t.Fatalf("expected only the failed cases to be reset, got %+v", m.testCases)
+	}
+	m = finish(m, 1, true)
+	m = finish(m, 2, false)
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsDoneMsg{Passed: 1, Total: 2, Err: errors.New("case 3 failed")}})
+	m = updated.(model)
+
+	res := m.results["a.cpp"]
+	if res.passed != 2 || res.total != 3 || res.err == nil {
+		t.Fatalf("expected 2/3 passing after the merge, got %d/%d, %v", res.passed, res.total, res.err)
+	}
+
+	// enter runs the selected case alone.
+	updated, _ = m.Update(keyMsg("j"))
+	m = updated.(model)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if req, ok := cmd().(runRequestMsg); !ok || fmt.Sprint(req.cases) != "[0]" {
+		t.Fatalf("expected a run of the selected case, got %#v", req)
+	}
+
+	// When a case was added meanwhile, the workflow runs every case.
+	updated, _ = m.Update(runRequestMsg{path: "a.cpp", cases: []int{2}})
+	m = updated.(model)
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 4}})
+	m = updated.(model)
+	if m.runningCases != nil || len(m.testCases) != 4 || m.testCases[0].Status != components.TestCasePending {
+		t.Fatalf("expected a full run of the 4 cases, got cases=%v %+v", m.runningCases, m.testCases)
+	}
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsDoneMsg{Passed: 4, Total: 4}})
+	m = updated.(model)
+	if res := m.results["a.cpp"]; res.passed != 4 || res.total != 4 || res.err != nil {
+		t.Fatalf("expected 4/4 passing, got %d/%d, %v", res.passed, res.total, res.err)
+	}
+}
+
+func TestShrunkCasesCannotBeAccepted(t *testing.T) {
+	m := newModel(appConfig{}, "a.cpp")
+	m.resetForNewRun("a.cpp")
+	updated, _ := m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 1}})
+	m = updated.(model)
+	updated, _ = m.Update(runnerUpdateMsg{msg: testStatusMsg{Current: 1, Total: 1, Status: testStatusFailed, CompileSuccess: true, ActualOutput: "3"}})
+	m = updated.(model)
+	updated, _ = m.Update(keyMsg("j"))
+	m = updated.(model)
+	if _, cmd := m.Update(keyMsg("a")); cmd == nil {
+		t.Fatal("expected the case's own output to be accepted")
+	}
+
+	updated, _ = m.Update(caseShrunkMsg{path: "a.cpp", result: shrinkResult{Index: 0, Inputs: []string{"1"}, Actual: []string{"2"}}})
+	m = updated.(model)
+	if !m.testCases[0].Shrunk || m.testCases[0].ActualOutput != "2" {
+		t.Fatalf("expected the shrunk case in the details, got %+v", m.testCases[0])
+	}
+	updated, cmd := m.Update(keyMsg("a"))
+	if m = updated.(model); cmd != nil || m.footerStatus != "Shrunk cases cannot be accepted" {
+		t.Fatalf("expected accept to be refused, got %q", m.footerStatus)
+	}
+}
+
+func TestQueuedRerunKeepsItsCases(t *testing.T) {
+	m := newModel(appConfig{}, "a.cpp")
+	m.resetForNewRun("b.cpp")
+
+	updated, _ := m.Update(runRequestMsg{path: "a.cpp", cases: []int{2}})
+	m = updated.(model)
+	updated, _ = m.Update(runRequestMsg{path: "a.cpp", cases: []int{0, 2}})
+	m = updated.(model)
+	if m.pendingPath != "a.cpp" || fmt.Sprint(m.pendingCases) != "[0 2]" {
+		t.Fatalf("expected the subsets of a.cpp to be queued together, got %q %v", m.pendingPath, m.pendingCases)
+	}
+
+	// A save of a.cpp makes every case stale.
+	updated, _ = m.Update(watcherUpdateMsg{msg: watchEventMsg{Path: "a.cpp"}})
+	m = updated.(model)
+	if !m.hasPending || m.pendingCases != nil {
+		t.Fatalf("expected a queued run of every case, got %v", m.pendingCases)
+	}
+}
+
+func TestMouseSelectsScrollsAndOpensMenus(t *testing.T) {
+	m := newModel(appConfig{}, "a.cpp")
+	m.files = []string{"a.cpp", "b.cpp"}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(model)
+	m.resetForNewRun("a.cpp")
+	updated, _ = m.Update(runnerUpdateMsg{msg: testsInitMsg{Total: 5}})
+	m = updated.(model)
+	var lines []string
+	for i := 0; i < 40; i++ {
+		lines = append(lines, fmt.Sprint(i))
+	}
+	for i := 1; i <= 5; i++ {
+		updated, _ = m.Update(runnerUpdateMsg{msg: testStatusMsg{Current: i, Total: 5, Status: testStatusPassed, Inputs: []string{"1"}, ExpectedOutput: strings.Join(lines, "\n"), ActualOutput: strings.Join(lines, "\n")}})
+		m = updated.(model)
+	}
+
+	// find returns the screen cell where text is first drawn.
+	find := func(m model, text string) (int, int) {
+		t.Helper()
+		for y, line := range strings.Split(ansi.Strip(m.View()), "\n") {
+			if i := strings.Index(line, text); i >= 0 {
+				return ansi.StringWidth(line[:i]), y
+			}
+		}
+		t.Fatalf("%q not on screen:\n%s", text, m.View())
+		return 0, 0
+	}
+	press := func(m model, button tea.MouseButton, x, y int) model {
+		updated, _ := m.Update(tea.MouseMsg{X: x, Y: y, Button: button, Action: tea.MouseActionPress})
+		return updated.(model)
+	}
+
+	x, y := find(m, "Case 3")
+	if m = press(m, tea.MouseButtonLeft, x, y); m.selectedIndex != 2 {
+		t.Fatalf("expected a click to select case 3, got %d", m.selectedIndex)
+	}
+
+	x, y = find(m, "OUTPUT")
+	if m = press(m, tea.MouseButtonWheelDown, x, y+3); m.detailsScroll.Focused != 1 || m.detailsScroll.Outputs != mouseWheelStep {
+		t.Fatalf("expected the wheel to scroll the outputs, got %+v", m.detailsScroll)
+	}
+	x, y = find(m, "INPUTS")
+	if m = press(m, tea.MouseButtonLeft, x, y+2); m.detailsScroll.Focused != 0 {
+		t.Fatalf("expected a click on the inputs to focus them, got %+v", m.detailsScroll)
+	}
+
+	if m = press(m, tea.MouseButtonLeft, 115, 39); m.menu == nil || m.menu.kind != menuFiles {
+		t.Fatalf("expected the filename tag to open the files menu")
+	}
+	x, y = find(m, "  b.cpp")
+	m = press(m, tea.MouseButtonLeft, x+2, y)
+	if m.menu != nil || m.activePath != "b.cpp" {
+		t.Fatalf("expected choosing b.cpp to switch to it, got %q", m.activePath)
+	}
+
+	if m = press(m, tea.MouseButtonLeft, 95, 39); m.menu == nil || m.menu.kind != menuCompileFlags {
+		t.Fatalf("expected the language tag to open the compile flags menu")
+	}
+	if m = press(m, tea.MouseButtonLeft, 0, 0); m.menu != nil {
+		t.Fatalf("expected a click outside to close the menu")
+	}
+}
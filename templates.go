@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// solutionTemplateData is exposed to solution templates as placeholders.
+type solutionTemplateData struct {
+	Name        string
+	Group       string
+	URL         string
+	Date        string
+	TimeLimit   string
+	MemoryLimit string
+	// Cases holds ready-to-embed INPUTS/OUTPUT sections for a defiprompt block.
+	Cases string
+}
+
+// builtinTemplates maps a source extension to its default solution template.
+var builtinTemplates = map[string]string{
+	".cpp": `// {{.Name}}
+{{- if .URL}}
+// {{.URL}}
+{{- end}}
+// Created {{.Date}}
+#include <bits/stdc++.h>
+using namespace std;
+
+/*defiprompt
+{{- if .TimeLimit}}
+TIME LIMIT: {{.TimeLimit}}
+{{- end}}
+{{- if .MemoryLimit}}
+MEMORY LIMIT: {{.MemoryLimit}}
+{{- end}}
+{{.Cases}}*/
+
+int main() {
+    ios::sync_with_stdio(false);
+    cin.tie(nullptr);
+
+    return 0;
+}
+`,
+}
+
+// newSolutionData fills template placeholders for a problem.
+func newSolutionData(name string, limits PromptLimits, cases []PromptCase) solutionTemplateData {
+	return solutionTemplateData{
+		Name:        name,
+		Date:        time.Now().Format("2006-01-02"),
+		TimeLimit:   formatTimeLimit(limits.Time),
+		MemoryLimit: formatMemoryLimit(limits.MemoryMB),
+		Cases:       formatPromptCases(cases),
+	}
+}
+
+// userTemplateDir is where users override templates, one "<lang>.tmpl" file
+// per language (e.g. "cpp.tmpl").
+func userTemplateDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "defi", "templates"), nil
+}
+
+// loadSolutionTemplate returns the template text for ext, preferring an
+// explicit path, then the user template directory, then the built-in one.
+func loadSolutionTemplate(ext, override string) (string, error) {
+	if override != "" {
+		data, err := os.ReadFile(override)
+		if err != nil {
+			return "", fmt.Errorf("failed to read template %q: %w", override, err)
+		}
+		return string(data), nil
+	}
+
+	if dir, err := userTemplateDir(); err == nil {
+		path := filepath.Join(dir, strings.TrimPrefix(ext, ".")+".tmpl")
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to read template %q: %w", path, err)
+		}
+	}
+
+	text, ok := builtinTemplates[ext]
+	if !ok {
+		return "", fmt.Errorf("no solution template for %q files", ext)
+	}
+	return text, nil
+}
+
+// renderSolutionTemplate instantiates the template for ext, optionally read
+// from an explicit override path.
+func renderSolutionTemplate(ext, override string, data solutionTemplateData) (string, error) {
+	text, err := loadSolutionTemplate(ext, override)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(ext).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template for %q: %w", ext, err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render template for %q: %w", ext, err)
+	}
+	return b.String(), nil
+}
+
+// formatPromptCases renders cases as the body of a defiprompt block.
+func formatPromptCases(cases []PromptCase) string {
+	var b strings.Builder
+	for i, c := range cases {
+		if i > 0 {
+			b.WriteString("-*-\n")
+		}
+		b.WriteString("INPUTS:\n")
+		for _, line := range c.Inputs {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("OUTPUT:\n")
+		if len(c.Outputs) == 0 {
+			b.WriteString("?\n")
+		}
+		for _, line := range c.Outputs {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+var nonFileNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// solutionFileName derives a file name such as "a_watermelon.cpp" from a
+// problem name.
+func solutionFileName(name, ext string) string {
+	base := strings.Trim(nonFileNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if base == "" {
+		base = "solution"
+	}
+	return base + ext
+}
+
+// createSolutionFile writes content to a new file in dir, adding a numeric
+// suffix instead of overwriting an existing solution.
+func createSolutionFile(dir, fileName, content string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %q: %w", dir, err)
+	}
+
+	ext := filepath.Ext(fileName)
+	stem := strings.TrimSuffix(fileName, ext)
+	for i := 1; ; i++ {
+		name := fileName
+		if i > 1 {
+			name = fmt.Sprintf("%s_%d%s", stem, i, ext)
+		}
+		path := filepath.Join(dir, name)
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create %q: %w", path, err)
+		}
+
+		_, err = f.WriteString(content)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write %q: %w", path, err)
+		}
+		return path, nil
+	}
+}
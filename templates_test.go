@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderSolutionTemplateUsesUserTemplate(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("HOME", configDir)
+
+	templateDir, err := userTemplateDir()
+	if err != nil {
+		t.Fatalf("userTemplateDir returned error: %v", err)
+	}
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("failed to create template dir: %v", err)
+	}
+	custom := "// {{.Name}} ({{.TimeLimit}}, {{.MemoryLimit}})\n/*defiprompt\n{{.Cases}}*/\n"
+	if err := os.WriteFile(filepath.Join(templateDir, "cpp.tmpl"), []byte(custom), 0o644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	data := newSolutionData("B. Sums", PromptLimits{Time: 2 * time.Second, MemoryMB: 256}, []PromptCase{
+		{Inputs: []string{"1 2"}, Outputs: []string{"3"}},
+	})
+	got, err := renderSolutionTemplate(".cpp", "", data)
+	if err != nil {
+		t.Fatalf("renderSolutionTemplate returned error: %v", err)
+	}
+
+	want := "// B. Sums (2s, 256MB)\n/*defiprompt\nINPUTS:\n1 2\nOUTPUT:\n3\n*/\n"
+	if got != want {
+		t.Fatalf("unexpected render:\n%s", got)
+	}
+}
+
+func TestBuiltinTemplateHasEmptyPromptBlock(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	got, err := renderSolutionTemplate(".cpp", "", newSolutionData("a", PromptLimits{}, nil))
+	if err != nil {
+		t.Fatalf("renderSolutionTemplate returned error: %v", err)
+	}
+	if !strings.Contains(got, "/*defiprompt\n*/") {
+		t.Fatalf("expected an empty defiprompt block:\n%s", got)
+	}
+
+	cases, err := parsePromptContent(got)
+	if err != nil || len(cases) != 0 {
+		t.Fatalf("expected no cases, got %v (%v)", cases, err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pedrohff/defi/components"
+)
+
+// themeAuto picks the dark or the light theme from the terminal background.
+const themeAuto = "auto"
+
+// resolveTheme returns the theme called name: one of custom, the user's
+// themes from the config file, or a built-in one. An empty name or "auto"
+// asks hasDarkBackground which of the dark and light themes to use.
+//
+// A custom theme starts from its "base", a built-in theme or "auto", and
+// overrides the colors it lists:
+//
+//	"themes": {"mine": {"base": "catppuccin-mocha", "accent": "#fab387"}}
+func resolveTheme(name string, custom map[string]json.RawMessage, hasDarkBackground func() bool) (components.Theme, error) {
+	if raw, ok := custom[name]; ok {
+		var base struct {
+			Base string `json:"base"`
+		}
+		if err := json.Unmarshal(raw, &base); err != nil {
+			return components.Theme{}, fmt.Errorf("theme %q: %w", name, err)
+		}
+		theme, err := builtinTheme(base.Base, hasDarkBackground)
+		if err != nil {
+			return components.Theme{}, fmt.Errorf("theme %q: %w", name, err)
+		}
+		if err := json.Unmarshal(raw, &theme); err != nil {
+			return components.Theme{}, fmt.Errorf("theme %q: %w", name, err)
+		}
+		theme.Name = name
+		return theme, nil
+	}
+	return builtinTheme(name, hasDarkBackground)
+}
+
+// builtinTheme returns the built-in theme called name, detecting the
+// background for an empty name or "auto".
+func builtinTheme(name string, hasDarkBackground func() bool) (components.Theme, error) {
+	if name == "" || name == themeAuto {
+		if hasDarkBackground() {
+			return components.DarkTheme, nil
+		}
+		return components.LightTheme, nil
+	}
+	theme, ok := components.ThemeByName(name)
+	if !ok {
+		return components.Theme{}, fmt.Errorf("unknown theme %q (want %s or one from the config file)", name, themeNames())
+	}
+	return theme, nil
+}
+
+// themeNames lists the themes accepted by --theme besides custom ones.
+func themeNames() string {
+	names := []string{themeAuto}
+	for _, t := range components.Themes {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// userTheme resolves the theme of the user config file, for the commands
+// without a --theme flag.
+func userTheme() (components.Theme, error) {
+	settings := userSettings{}
+	if path, err := userSettingsPath(); err == nil {
+		if settings, err = loadUserSettings(path); err != nil {
+			return components.Theme{}, err
+		}
+	}
+	return resolveTheme(settings.Theme, settings.Themes, lipgloss.HasDarkBackground)
+}
+
+// themeOrDefault falls back to the dark theme for a zero theme, as in tests
+// that build models without a config.
+func themeOrDefault(theme components.Theme) components.Theme {
+	if theme.Name == "" {
+		return components.DarkTheme
+	}
+	return theme
+}
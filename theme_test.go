@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pedrohff/defi/components"
+)
+
+func TestBuiltinThemesSetEveryColor(t *testing.T) {
+	for _, theme := range components.Themes {
+		v := reflect.ValueOf(theme)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).String() == "" {
+				t.Errorf("theme %q has no %s", theme.Name, v.Type().Field(i).Name)
+			}
+		}
+	}
+}
+
+func TestResolveThemeDetectsBackgroundAndAppliesOverrides(t *testing.T) {
+	dark := func() bool { return true }
+	light := func() bool { return false }
+
+	if theme, err := resolveTheme("", nil, light); err != nil || theme.Name != "light" {
+		t.Fatalf("expected the light theme on a light background, got %q, %v", theme.Name, err)
+	}
+	if theme, err := resolveTheme("auto", nil, dark); err != nil || theme.Name != "dark" {
+		t.Fatalf("expected the dark theme on a dark background, got %q, %v", theme.Name, err)
+	}
+	if theme, err := resolveTheme("catppuccin-latte", nil, dark); err != nil || theme != components.CatppuccinLatte {
+		t.Fatalf("expected the built-in theme by name, got %q, %v", theme.Name, err)
+	}
+
+	custom := map[string]json.RawMessage{
+		"mine":  json.RawMessage(`{"base": "catppuccin-mocha", "accent": "#fab387"}`),
+		"plain": json.RawMessage(`{"text": "#000000"}`),
+		"bad":   json.RawMessage(`{"base": "solarized"}`),
+	}
+	theme, err := resolveTheme("mine", custom, light)
+	if err != nil {
+		t.Fatalf("resolveTheme: %v", err)
+	}
+	want := components.CatppuccinMocha
+	want.Name, want.Accent = "mine", lipgloss.Color("#fab387")
+	if theme != want {
+		t.Fatalf("expected mocha with the accent overridden, got %+v", theme)
+	}
+	if theme, err := resolveTheme("plain", custom, light); err != nil || theme.Surface != components.LightTheme.Surface || theme.Text != "#000000" {
+		t.Fatalf("expected a custom theme without base to start from the detected one, got %+v, %v", theme, err)
+	}
+
+	for _, name := range []string{"bad", "nope"} {
+		if _, err := resolveTheme(name, custom, dark); err == nil {
+			t.Fatalf("expected theme %q to be rejected", name)
+		}
+	}
+}
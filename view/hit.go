@@ -0,0 +1,131 @@
+package view
+
+import (
+	"math"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pedrohff/defi/components"
+)
+
+// HitArea names the part of a MainView under a screen cell.
+type HitArea int
+
+const (
+	HitNone HitArea = iota
+	// HitCase is a row of the test case list; Hit.Index is the case.
+	HitCase
+	// HitDetails is the details pane; Hit.Section is the viewport, as
+	// returned by components.DetailsSectionAt.
+	HitDetails
+	// HitLanguage and HitFilename are the footer tags.
+	HitLanguage
+	HitFilename
+	// HitOverlay is the overlay; Hit.X and Hit.Y are relative to its top
+	// left corner.
+	HitOverlay
+)
+
+// Hit describes what is under a screen cell.
+type Hit struct {
+	Area    HitArea
+	Index   int
+	Section int
+	X, Y    int
+}
+
+// headerHeight is the header title and the margin under it.
+const headerHeight = 2
+
+// HitTest returns what Render draws at column x and row y.
+func (v *MainView) HitTest(x, y int) Hit {
+	if y == v.Height-1 {
+		switch components.FooterTagAt(v.Width, x) {
+		case components.FooterTagLanguage:
+			return Hit{Area: HitLanguage}
+		case components.FooterTagFilename:
+			return Hit{Area: HitFilename}
+		}
+		return Hit{}
+	}
+
+	bodyHeight := v.Height - chromeHeight
+	if v.Help != "" {
+		bodyHeight -= HelpBarHeight
+	}
+	y -= headerHeight
+	if y < 0 || y >= bodyHeight {
+		return Hit{}
+	}
+	width := v.Width
+	if len(v.Files) > 1 && v.Width >= 80 {
+		x -= components.FileListWidth
+		width -= components.FileListWidth
+	}
+	if x < 0 {
+		return Hit{}
+	}
+
+	if v.Overlay != nil {
+		overlay := v.Overlay(width, bodyHeight)
+		ox, oy := x-placeOffset(width, overlay, lipgloss.Width), y-placeOffset(bodyHeight, overlay, lipgloss.Height)
+		if ox < 0 || oy < 0 || ox >= lipgloss.Width(overlay) || oy >= lipgloss.Height(overlay) {
+			return Hit{}
+		}
+		return Hit{Area: HitOverlay, X: ox, Y: oy}
+	}
+	return v.hitBody(width, bodyHeight, x, y)
+}
+
+// hitBody hit-tests the body as laid out by renderBody.
+func (v *MainView) hitBody(width, height, x, y int) Hit {
+	hasDetails := v.Panel != nil || (v.SelectedIndex >= 0 && v.SelectedIndex < len(v.TestCases))
+	layout := v.Layout.effective(v.Panel != nil, hasDetails)
+	box := arrange(layout, width, height, len(v.TestCases))
+
+	// The list starts under its header row at the top left.
+	if layout != LayoutMaximized && x < box.listWidth && y >= 1 && y <= box.listRows {
+		offset := ScrollOffset(v.ListOffset, v.SelectedIndex, box.listRows, len(v.TestCases))
+		return Hit{Area: HitCase, Index: offset + y - 1}
+	}
+
+	details := v.renderDetails(layout, box)
+	if details == "" {
+		return Hit{}
+	}
+	var dx, dy int
+	switch layout {
+	case LayoutSplitVertical:
+		dx = x - box.listWidth - placeOffset(width-box.listWidth, details, lipgloss.Width)
+		dy = y - placeOffset(height, details, lipgloss.Height)
+	case LayoutMaximized:
+		dx = x - placeOffset(width, details, lipgloss.Width)
+		dy = y - placeOffset(height, details, lipgloss.Height)
+	default:
+		dx = x - joinOffset(width, details)
+		dy = y - box.listRows - 1 - placeOffset(box.detailsHeight, details, lipgloss.Height)
+	}
+	if dx < 0 || dy < 0 || dx >= lipgloss.Width(details) || dy >= lipgloss.Height(details) {
+		return Hit{}
+	}
+
+	hit := Hit{Area: HitDetails, Section: -1, X: dx, Y: dy}
+	if v.Panel == nil {
+		tc := v.TestCases[v.SelectedIndex]
+		hit.Section = components.DetailsSectionAt(box.caseWidth, box.caseHeight(),
+			len(tc.Inputs), outputLines(tc.ExpectedOutput), outputLines(tc.ActualOutput), dx, dy)
+	}
+	return hit
+}
+
+// placeOffset is where lipgloss.Place centers content within size cells.
+func placeOffset(size int, content string, measure func(string) int) int {
+	gap := max(size-measure(content), 0)
+	return gap - int(math.Round(float64(gap)/2))
+}
+
+// joinOffset is where lipgloss.JoinVertical centers content next to blocks
+// width cells wide.
+func joinOffset(width int, content string) int {
+	gap := max(width-lipgloss.Width(content), 0)
+	return int(math.Round(float64(gap) / 2))
+}
@@ -0,0 +1,135 @@
+package view
+
+import (
+	"strings"
+
+	"github.com/pedrohff/defi/components"
+)
+
+// Layout arranges the test case list and the details pane of a MainView.
+type Layout string
+
+const (
+	// LayoutSplitHorizontal stacks the details pane under the list.
+	LayoutSplitHorizontal Layout = "split-horizontal"
+	// LayoutSplitVertical puts the details pane to the right of the list.
+	LayoutSplitVertical Layout = "split-vertical"
+	// LayoutListOnly shows the list alone, using the whole body for rows.
+	LayoutListOnly Layout = "list"
+	// LayoutMaximized shows the selected case's details alone.
+	LayoutMaximized Layout = "maximized"
+)
+
+// Layouts lists the layouts in the order they are cycled through.
+var Layouts = []Layout{LayoutSplitHorizontal, LayoutSplitVertical, LayoutListOnly, LayoutMaximized}
+
+// ParseLayout returns the layout named name.
+func ParseLayout(name string) (Layout, bool) {
+	for _, l := range Layouts {
+		if string(l) == name {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Next returns the layout after l, wrapping around.
+func (l Layout) Next() Layout {
+	for i, candidate := range Layouts {
+		if candidate == l {
+			return Layouts[(i+1)%len(Layouts)]
+		}
+	}
+	return Layouts[1]
+}
+
+const (
+	// minListRows is the fewest test case rows kept next to a details pane.
+	minListRows = 3
+	// minSideListWidth is the narrowest list next to a details pane.
+	minSideListWidth = 40
+)
+
+// effective resolves the layout actually drawn: with nothing to detail the
+// list is shown alone, and a panel, such as the new case form, is never
+// hidden.
+func (l Layout) effective(hasPanel, hasDetails bool) Layout {
+	switch {
+	case !hasDetails:
+		return LayoutListOnly
+	case l == "", l == LayoutListOnly && hasPanel:
+		return LayoutSplitHorizontal
+	}
+	return l
+}
+
+// bodyBox sizes the parts of a MainView body.
+type bodyBox struct {
+	listWidth int
+	listRows  int
+	// detailsHeight is the height left for the details pane. Panels are
+	// drawn panelWidth wide and test case details caseWidth wide; both add
+	// their own border and padding.
+	detailsHeight int
+	panelWidth    int
+	caseWidth     int
+}
+
+// caseHeight is the height test case details are drawn with, leaving room
+// for their container's border and padding.
+func (box bodyBox) caseHeight() int {
+	return box.detailsHeight - 4
+}
+
+// arrange sizes the list and the details pane of a body for layout.
+func arrange(layout Layout, width, height, total int) bodyBox {
+	box := bodyBox{listWidth: width}
+	switch layout {
+	case LayoutSplitVertical:
+		box.listWidth = min(max(width*2/5, minSideListWidth), width)
+		box.listRows = min(total, max(height-1, 0))
+		box.detailsHeight = height
+		box.panelWidth = max(width-box.listWidth-4, 0)
+		box.caseWidth = max(width-box.listWidth-10, 0)
+	case LayoutMaximized:
+		box.detailsHeight = height
+		box.panelWidth = max(width-4, 0)
+		box.caseWidth = max(width-10, 0)
+	case LayoutListOnly:
+		box.listRows = min(total, max(height-1, 0))
+	default:
+		// The list takes at most half of the body when it is long, so the
+		// details stay readable.
+		box.listRows = max(min(total, max((height-1)/2, minListRows)), 0)
+		box.detailsHeight = max(height-box.listRows-1, 0)
+		box.panelWidth = width / 2
+		if width < 80 {
+			box.panelWidth = width - 10
+		}
+		box.caseWidth = box.panelWidth
+	}
+	return box
+}
+
+// ListRows returns how many of total test case rows a MainView of the given
+// height shows at once in layout.
+func ListRows(layout Layout, height int, details bool, total int) int {
+	return arrange(layout.effective(false, details), 0, height-chromeHeight, total).listRows
+}
+
+// DetailsScrollLimits returns the largest useful inputs and outputs scroll
+// offsets for tc's details in a MainView of the given height listing total
+// test cases.
+func DetailsScrollLimits(layout Layout, height, total int, tc TestCaseData) (inputs, outputs int) {
+	box := arrange(layout.effective(false, true), 0, height-chromeHeight, total)
+	expected, actual := outputLines(tc.ExpectedOutput), outputLines(tc.ActualOutput)
+	top, bottom := components.DetailsViewportRows(box.caseHeight(), len(tc.Inputs), expected, actual)
+	return max(len(tc.Inputs)-top, 0), max(expected-top, actual-bottom, 0)
+}
+
+func outputLines(output string) int {
+	if output == "" {
+		return 0
+	}
+	return strings.Count(output, "\n") + 1
+}
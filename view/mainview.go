@@ -2,6 +2,8 @@
 package view
 
 import (
+	"fmt"
+
 	"github.com/charmbracelet/lipgloss"
 	"github.com/pedrohff/defi/components"
 )
@@ -16,6 +18,20 @@ type TestCaseData struct {
 	Inputs           []string
 	ExpectedOutput   string
 	ActualOutput     string
+	// Regression is set when the case did better in the previous run.
+	Regression string
+	// Shrunk is set when the case shows a shrunk input rather than the one
+	// in the file.
+	Shrunk bool
+}
+
+// FileData summarises a watched file for the sidebar.
+type FileData struct {
+	Name   string
+	Status string
+	Passed int
+	Total  int
+	Active bool
 }
 
 // MainView encapsulates everything required to render the primary Défi screen.
@@ -27,8 +43,34 @@ type MainView struct {
 	Filename      string
 	Language      string
 	Status        string
+	// Panel, when set, replaces the details pane with custom content sized
+	// to the space left below the test list.
+	Panel func(width, height int) string
+	// Files lists the watched files; the sidebar is shown when there are several.
+	Files []FileData
+	// ListOffset is the first test case row shown when the list does not
+	// fit; it is adjusted so the selected row stays visible.
+	ListOffset int
+	// DetailsScroll scrolls the viewports of the selected case's details.
+	DetailsScroll components.DetailsScroll
+	// Layout arranges the list and the details pane.
+	Layout Layout
+	// Help is the key help shown in a bar above the footer.
+	Help string
+	// Overlay, when set, is rendered to fit the body and shown centered in
+	// its place.
+	Overlay func(width, height int) string
+	// Theme colors every component of the view.
+	Theme components.Theme
 }
 
+// chromeHeight is the header and footer height around the body.
+const chromeHeight = 3
+
+// HelpBarHeight is the height the help bar takes from the body. Callers
+// sizing the body for a view with help subtract it from their height.
+const HelpBarHeight = 1
+
 // MainViewOption defines a functional option for configuring MainView.
 type MainViewOption func(*MainView)
 
@@ -60,6 +102,75 @@ func WithStatus(status string) MainViewOption {
 	}
 }
 
+// WithPanel replaces the details pane with content rendered by panel.
+func WithPanel(panel func(width, height int) string) MainViewOption {
+	return func(v *MainView) {
+		v.Panel = panel
+	}
+}
+
+// WithListOffset sets the first test case row shown in a scrolled list.
+func WithListOffset(offset int) MainViewOption {
+	return func(v *MainView) {
+		v.ListOffset = offset
+	}
+}
+
+// ScrollOffset returns the first row to show so that selected is visible,
+// moving as little as possible from offset. selected < 0 keeps offset.
+func ScrollOffset(offset, selected, rows, total int) int {
+	if selected >= 0 && rows > 0 {
+		if selected < offset {
+			offset = selected
+		} else if selected >= offset+rows {
+			offset = selected - rows + 1
+		}
+	}
+	return max(min(offset, total-rows), 0)
+}
+
+// WithDetailsScroll sets the scroll state of the details pane.
+func WithDetailsScroll(scroll components.DetailsScroll) MainViewOption {
+	return func(v *MainView) {
+		v.DetailsScroll = scroll
+	}
+}
+
+// WithLayout sets how the list and the details pane are arranged.
+func WithLayout(layout Layout) MainViewOption {
+	return func(v *MainView) {
+		v.Layout = layout
+	}
+}
+
+// WithHelp shows the key help in a bar above the footer.
+func WithHelp(help string) MainViewOption {
+	return func(v *MainView) {
+		v.Help = help
+	}
+}
+
+// WithOverlay shows content rendered by overlay centered in place of the body.
+func WithOverlay(overlay func(width, height int) string) MainViewOption {
+	return func(v *MainView) {
+		v.Overlay = overlay
+	}
+}
+
+// WithTheme sets the colors of the view.
+func WithTheme(theme components.Theme) MainViewOption {
+	return func(v *MainView) {
+		v.Theme = theme
+	}
+}
+
+// WithFiles sets the watched files listed in the sidebar.
+func WithFiles(files []FileData) MainViewOption {
+	return func(v *MainView) {
+		v.Files = files
+	}
+}
+
 // NewMainView constructs a MainView with required parameters and optional configuration.
 // Required: width, height, testCases. Optional fields can be set via functional options.
 func NewMainView(width, height int, testCases []TestCaseData, opts ...MainViewOption) *MainView {
@@ -71,6 +182,7 @@ func NewMainView(width, height int, testCases []TestCaseData, opts ...MainViewOp
 		Language:      "-",
 		Filename:      "-",
 		Status:        "Idle",
+		Theme:         components.DarkTheme,
 	}
 
 	for _, opt := range opts {
@@ -80,61 +192,135 @@ func NewMainView(width, height int, testCases []TestCaseData, opts ...MainViewOp
 	return v
 }
 
-// Render composes the header, test case list, optional details pane, and footer.
+// Render composes the header, optional file sidebar, test case list, optional
+// details pane, optional help bar, and footer.
 func (v *MainView) Render() string {
-	header := components.Header(v.Width, " Défi")
+	header := components.Header(v.Theme, v.Width, " Défi")
 
-	// Build test case rows
-	rows := []string{components.TestCaseHeader(v.Width)}
-	for i, tc := range v.TestCases {
-		focused := i == v.SelectedIndex
-		row := components.TestCase(
-			v.Width,
-			tc.Name,
-			tc.Status,
-			tc.CompileSuccess,
-			tc.AssertionSuccess,
-			focused,
-		)
-		rows = append(rows, row)
+	footer := components.Footer(v.Theme, v.Width, v.Status, v.Language, v.Filename)
+
+	bodyHeight := v.Height - chromeHeight
+	var helpBar string
+	if v.Help != "" {
+		helpBar = components.HelpBar(v.Width, v.Help)
+		bodyHeight -= HelpBarHeight
+	}
+	if bodyHeight < 0 {
+		bodyHeight = 0
 	}
-	testList := lipgloss.JoinVertical(lipgloss.Top, rows...)
 
-	footer := components.Footer(v.Width, v.Status, v.Language, v.Filename)
+	// Show the file sidebar when several files are watched and there is room.
+	width := v.Width
+	var sidebar string
+	if len(v.Files) > 1 && v.Width >= 80 {
+		entries := make([]components.FileListEntry, len(v.Files))
+		for i, f := range v.Files {
+			entries[i] = components.FileListEntry(f)
+		}
+		sidebar = components.FileList(v.Theme, components.FileListWidth, bodyHeight, entries)
+		width -= components.FileListWidth
+	}
 
-	// Compute remaining vertical space for details pane
-	headerHeight := 2
-	listHeight := len(v.TestCases) + 1 // +1 for header row
-	footerHeight := 1
-	leftover := v.Height - headerHeight - listHeight - footerHeight
-	if leftover < 0 {
-		leftover = 0
+	var body string
+	if v.Overlay != nil {
+		body = lipgloss.Place(width, bodyHeight, lipgloss.Center, lipgloss.Center, v.Overlay(width, bodyHeight))
+	} else {
+		body = v.renderBody(width, bodyHeight)
+	}
+	if sidebar != "" {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, sidebar, body)
 	}
 
-	// Build details pane if a test is selected
-	var details string
-	testCaseDetailsWidth := v.Width / 2
-	if v.Width < 80 {
-		testCaseDetailsWidth = v.Width - 10
+	parts := []string{header, body}
+	if helpBar != "" {
+		parts = append(parts, helpBar)
 	}
-	if v.SelectedIndex >= 0 && v.SelectedIndex < len(v.TestCases) {
-		tc := v.TestCases[v.SelectedIndex]
-		details = components.TestCaseDetails(
-			testCaseDetailsWidth,
-			leftover-4,
-			tc.Name,
-			tc.Inputs,
-			tc.ExpectedOutput,
-			tc.ActualOutput,
+	return lipgloss.JoinVertical(
+		lipgloss.Center,
+		append(parts, footer)...,
+	)
+}
+
+// renderBody composes the test case list and the details pane within the
+// given box, arranged by the layout.
+func (v *MainView) renderBody(width, height int) string {
+	hasDetails := v.Panel != nil || (v.SelectedIndex >= 0 && v.SelectedIndex < len(v.TestCases))
+	layout := v.Layout.effective(v.Panel != nil, hasDetails)
+	box := arrange(layout, width, height, len(v.TestCases))
+
+	var testList string
+	if layout != LayoutMaximized {
+		testList = v.renderList(box.listWidth, box.listRows)
+	}
+	details := v.renderDetails(layout, box)
+
+	switch layout {
+	case LayoutSplitVertical:
+		return lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			lipgloss.PlaceVertical(height, lipgloss.Top, testList),
+			lipgloss.Place(width-box.listWidth, height, lipgloss.Center, lipgloss.Center, details),
 		)
+	case LayoutMaximized:
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, details)
+	case LayoutListOnly:
+		return lipgloss.PlaceVertical(height, lipgloss.Top, testList)
 	}
-	detailsPane := lipgloss.PlaceVertical(leftover, lipgloss.Center, details)
 
-	return lipgloss.JoinVertical(
+	detailsPane := lipgloss.PlaceVertical(box.detailsHeight, lipgloss.Center, details)
+	return lipgloss.PlaceHorizontal(width, lipgloss.Center, lipgloss.JoinVertical(
 		lipgloss.Center,
-		header,
 		testList,
 		detailsPane,
-		footer,
+	))
+}
+
+// renderDetails renders the panel or the selected case's details for the
+// layout, or "" when there is nothing to show.
+func (v *MainView) renderDetails(layout Layout, box bodyBox) string {
+	if v.Panel != nil {
+		return v.Panel(box.panelWidth, box.detailsHeight)
+	}
+	if layout == LayoutListOnly || v.SelectedIndex < 0 || v.SelectedIndex >= len(v.TestCases) {
+		return ""
+	}
+	tc := v.TestCases[v.SelectedIndex]
+	return components.TestCaseDetails(
+		v.Theme,
+		box.caseWidth,
+		box.caseHeight(),
+		tc.Name,
+		tc.Inputs,
+		tc.ExpectedOutput,
+		tc.ActualOutput,
+		v.DetailsScroll,
 	)
 }
+
+// renderList renders the header and visible rows of the test case list.
+func (v *MainView) renderList(width, visible int) string {
+	offset := ScrollOffset(v.ListOffset, v.SelectedIndex, visible, len(v.TestCases))
+
+	// Build test case rows, showing the position once the list scrolls.
+	position := ""
+	if visible < len(v.TestCases) {
+		position = fmt.Sprintf("%d-%d of %d", offset+1, offset+visible, len(v.TestCases))
+	}
+	rows := []string{components.TestCaseHeader(v.Theme, width, position)}
+	for i := offset; i < offset+visible; i++ {
+		tc := v.TestCases[i]
+		focused := i == v.SelectedIndex
+		row := components.TestCase(
+			v.Theme,
+			width,
+			tc.Name,
+			tc.Status,
+			tc.CompileSuccess,
+			tc.AssertionSuccess,
+			tc.Regression,
+			focused,
+		)
+		rows = append(rows, row)
+	}
+	return lipgloss.JoinVertical(lipgloss.Top, rows...)
+}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
 )
 
 type watchMode int
@@ -23,6 +26,18 @@ type watchSpec struct {
 	filePath string
 	dir      string
 	pattern  string
+	// recursive extends directory mode to every non-ignored subdirectory.
+	recursive bool
+	// ignore holds gitignore-style patterns applied on top of .gitignore files.
+	ignore []string
+}
+
+// watchTarget is a candidate source. ModTime is the newest modification time
+// among the source and the local headers it includes.
+type watchTarget struct {
+	Path    string
+	ModTime time.Time
+	Deps    []string
 }
 
 var errNoMatchingFiles = errors.New("no matching files found")
@@ -37,6 +52,11 @@ func parseWatchSpec(input string) (watchSpec, error) {
 
 	if hasGlob(cleaned) {
 		dir := filepath.Dir(cleaned)
+		if idx := strings.Index(dir, "**"); idx != -1 {
+			// "contest/**/*.cpp" watches every subdirectory of contest.
+			dir = filepath.Clean(dir[:idx] + ".")
+			spec.recursive = true
+		}
 		if dir == "" {
 			dir = "."
 		}
@@ -68,69 +88,129 @@ func hasGlob(path string) bool {
 }
 
 func resolveLatestTarget(spec watchSpec) (string, time.Time, error) {
+	target, err := latestTarget(spec, newIncludeCache())
+	return target.Path, target.ModTime, err
+}
+
+// latestTarget returns the most recently changed source matched by spec,
+// counting changes to the headers it includes.
+func latestTarget(spec watchSpec, cache *includeCache) (watchTarget, error) {
+	targets, err := listWatchTargets(spec, cache)
+	if err != nil {
+		return watchTarget{}, err
+	}
+
+	var latest watchTarget
+	for _, target := range targets {
+		if latest.Path == "" || target.ModTime.After(latest.ModTime) {
+			latest = target
+		}
+	}
+
+	if latest.Path == "" {
+		return watchTarget{}, errNoMatchingFiles
+	}
+	return latest, nil
+}
+
+// listWatchTargets returns every source matched by spec with its local
+// header dependencies.
+func listWatchTargets(spec watchSpec, cache *includeCache) ([]watchTarget, error) {
+	var paths []string
 	switch spec.mode {
 	case watchModeFile:
-		info, err := os.Stat(spec.filePath)
-		if err != nil {
+		if _, err := os.Stat(spec.filePath); err != nil {
 			if errors.Is(err, os.ErrNotExist) {
-				return "", time.Time{}, errNoMatchingFiles
+				return nil, errNoMatchingFiles
 			}
-			return "", time.Time{}, err
+			return nil, err
 		}
-		return spec.filePath, info.ModTime(), nil
+		paths = []string{spec.filePath}
 
 	case watchModeDirectory:
-		entries, err := os.ReadDir(spec.dir)
-		if err != nil {
-			return "", time.Time{}, err
+		var err error
+		if paths, err = spec.candidatePaths(); err != nil {
+			return nil, err
 		}
+	default:
+		return nil, errors.New("unknown watch mode")
+	}
 
-		var (
-			latestPath string
-			latestTime time.Time
-		)
-
-		for _, entry := range entries {
-			if entry.IsDir() {
+	targets := make([]watchTarget, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Removed between listing and stat, e.g. during an atomic save.
 				continue
 			}
+			return nil, err
+		}
+
+		deps, modTime := cache.dependencies(path, info.ModTime())
+		targets = append(targets, watchTarget{Path: path, ModTime: modTime, Deps: deps})
+	}
+	return targets, nil
+}
+
+// candidatePaths lists the files of a directory spec that match its pattern
+// (or a supported language) and are not ignored.
+func (spec watchSpec) candidatePaths() ([]string, error) {
+	ignore := newIgnoreMatcher(spec.ignore)
+	if err := ignore.addGitignore(spec.dir, "."); err != nil {
+		return nil, err
+	}
+
+	if !spec.recursive {
+		entries, err := os.ReadDir(spec.dir)
+		if err != nil {
+			return nil, err
+		}
 
+		var paths []string
+		for _, entry := range entries {
 			name := entry.Name()
-			if spec.pattern != "" {
-				matched, err := filepath.Match(spec.pattern, name)
-				if err != nil {
-					return "", time.Time{}, err
-				}
-				if !matched {
-					continue
-				}
-			} else {
-				if _, ok := supportedLanguages[filepath.Ext(name)]; !ok {
-					continue
-				}
+			if entry.IsDir() || !spec.matchesName(name) || ignore.Ignored(name, false) {
+				continue
 			}
+			paths = append(paths, filepath.Join(spec.dir, name))
+		}
+		return paths, nil
+	}
 
-			path := filepath.Join(spec.dir, name)
-			info, err := entry.Info()
-			if err != nil {
-				return "", time.Time{}, err
-			}
+	var paths []string
+	err := filepath.WalkDir(spec.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(spec.dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
 
-			modTime := info.ModTime()
-			if latestPath == "" || modTime.After(latestTime) {
-				latestPath = path
-				latestTime = modTime
+		if entry.IsDir() {
+			if ignore.Ignored(rel, true) {
+				return filepath.SkipDir
 			}
+			return ignore.addGitignore(spec.dir, rel)
 		}
-
-		if latestPath == "" {
-			return "", time.Time{}, errNoMatchingFiles
+		if spec.matchesName(entry.Name()) && !ignore.Ignored(rel, false) {
+			paths = append(paths, path)
 		}
+		return nil
+	})
+	return paths, err
+}
 
-		return latestPath, latestTime, nil
-	default:
-		return "", time.Time{}, errors.New("unknown watch mode")
+// matchesName reports whether a file name is a candidate for directory mode.
+func (spec watchSpec) matchesName(name string) bool {
+	if spec.pattern != "" {
+		matched, err := filepath.Match(spec.pattern, name)
+		return err == nil && matched
 	}
+	_, ok := supportedLanguages[filepath.Ext(name)]
+	return ok
 }
 
 type watchEventMsg struct {
@@ -141,6 +221,11 @@ type watchEventMsg struct {
 
 type watchIdleMsg struct{}
 
+// watchFilesMsg lists every source currently matched by the watch spec.
+type watchFilesMsg struct {
+	Paths []string
+}
+
 type watchErrMsg struct {
 	Err error
 }
@@ -154,57 +239,255 @@ type watcherUpdateMsg struct {
 	done bool
 }
 
-func startWatcherCmd(spec watchSpec, interval time.Duration) tea.Cmd {
+// fsEventSettle coalesces the burst of rename/write/chmod events editors emit
+// for a single atomic save.
+const fsEventSettle = 25 * time.Millisecond
+
+func startWatcherCmd(spec watchSpec, interval time.Duration, poll bool) tea.Cmd {
 	if interval <= 0 {
 		interval = time.Second
 	}
 
 	return func() tea.Msg {
 		ch := make(chan tea.Msg, 16)
-		go watchLoop(spec, interval, ch)
+		go func() {
+			if !poll {
+				if watcher, err := newFSWatcher(spec); err == nil {
+					watchEvents(spec, watcher, ch)
+					return
+				}
+			}
+			watchLoop(spec, interval, ch)
+		}()
 		return watcherStartedMsg{ch: ch}
 	}
 }
 
+// watchState remembers the modification time reported for every target so
+// that only real changes are forwarded to the UI.
+type watchState struct {
+	spec        watchSpec
+	includes    *includeCache
+	first       bool
+	lastHadFile bool
+	// seen maps each known target to the modification time last reported.
+	seen map[string]time.Time
+	// deps holds the headers included by the watched sources.
+	deps map[string]bool
+}
+
+func newWatchState(spec watchSpec) *watchState {
+	return &watchState{
+		spec:     spec,
+		includes: newIncludeCache(),
+		first:    true,
+		seen:     make(map[string]time.Time),
+		deps:     make(map[string]bool),
+	}
+}
+
+// check lists the current targets and emits the matching watcher messages:
+// the target list when it changes, the newest target on the first check and
+// every changed target afterwards.
+func (s *watchState) check(ch chan<- tea.Msg) {
+	defer func() { s.first = false }()
+
+	targets, err := listWatchTargets(s.spec, s.includes)
+	if err == nil && len(targets) == 0 {
+		err = errNoMatchingFiles
+	}
+	if err != nil {
+		if errors.Is(err, errNoMatchingFiles) {
+			if s.lastHadFile || s.first {
+				ch <- watchIdleMsg{}
+				ch <- watchFilesMsg{}
+			}
+			s.lastHadFile = false
+			clear(s.seen)
+		} else {
+			ch <- watchErrMsg{Err: err}
+		}
+		return
+	}
+	s.lastHadFile = true
+
+	// Oldest first, so the most recent change is reported last.
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].ModTime.Before(targets[j].ModTime)
+	})
+
+	clear(s.deps)
+	listChanged := s.first || len(targets) != len(s.seen)
+	var changed []watchTarget
+	for _, target := range targets {
+		for _, dep := range target.Deps {
+			s.deps[dep] = true
+		}
+		last, known := s.seen[target.Path]
+		if !known {
+			listChanged = true
+		}
+		if !known || target.ModTime.After(last) {
+			changed = append(changed, target)
+		}
+	}
+
+	if listChanged {
+		paths := make([]string, len(targets))
+		for i, target := range targets {
+			paths[i] = target.Path
+		}
+		sort.Strings(paths)
+		ch <- watchFilesMsg{Paths: paths}
+	}
+
+	if s.first {
+		latest := targets[len(targets)-1]
+		ch <- watchEventMsg{Path: latest.Path, ModTime: latest.ModTime, Initial: true}
+	} else {
+		for _, target := range changed {
+			ch <- watchEventMsg{Path: target.Path, ModTime: target.ModTime}
+		}
+	}
+
+	clear(s.seen)
+	for _, target := range targets {
+		s.seen[target.Path] = target.ModTime
+	}
+}
+
+// watchLoop polls the target every interval; it is the fallback when file
+// system notifications are unavailable.
 func watchLoop(spec watchSpec, interval time.Duration, ch chan<- tea.Msg) {
 	defer close(ch)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	first := true
-	var (
-		lastPath    string
-		lastTime    time.Time
-		lastHadFile bool
-	)
-
+	state := newWatchState(spec)
 	for {
-		path, modTime, err := resolveLatestTarget(spec)
-		if err != nil {
-			if errors.Is(err, errNoMatchingFiles) {
-				if lastHadFile || first {
-					ch <- watchIdleMsg{}
-				}
-				lastHadFile = false
-			} else {
-				ch <- watchErrMsg{Err: err}
+		state.check(ch)
+
+		if _, ok := <-ticker.C; !ok {
+			return
+		}
+	}
+}
+
+// newFSWatcher subscribes to notifications for the watched directory and,
+// in recursive mode, its subdirectories. Directories rather than files are
+// watched so that editors replacing the file on save keep being tracked.
+func newFSWatcher(spec watchSpec) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := addWatchDirs(watcher, spec, spec.dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// addWatchDirs watches dir and, in recursive mode, every non-ignored
+// directory below it.
+func addWatchDirs(watcher *fsnotify.Watcher, spec watchSpec, dir string) error {
+	if !spec.recursive {
+		return watcher.Add(dir)
+	}
+
+	ignore := newIgnoreMatcher(spec.ignore)
+	if err := ignore.addGitignore(spec.dir, "."); err != nil {
+		return err
+	}
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+		if rel, relErr := filepath.Rel(spec.dir, path); relErr == nil && rel != "." {
+			rel = filepath.ToSlash(rel)
+			if ignore.Ignored(rel, true) {
+				return filepath.SkipDir
 			}
-		} else {
-			lastHadFile = true
-			if first || path != lastPath || modTime.After(lastTime) {
-				ch <- watchEventMsg{Path: path, ModTime: modTime, Initial: first}
-				lastPath = path
-				lastTime = modTime
+			if err := ignore.addGitignore(spec.dir, rel); err != nil {
+				return err
 			}
 		}
+		return watcher.Add(path)
+	})
+}
 
-		first = false
+// watchEvents reacts to file system notifications, checking the target once
+// a burst of events has settled.
+func watchEvents(spec watchSpec, watcher *fsnotify.Watcher, ch chan<- tea.Msg) {
+	defer close(ch)
+	defer watcher.Close()
 
-		if _, ok := <-ticker.C; !ok {
-			return
+	watched := make(map[string]bool)
+	for _, dir := range watcher.WatchList() {
+		watched[dir] = true
+	}
+	// watchDeps follows headers living outside the watched directories.
+	watchDeps := func(state *watchState) {
+		for dep := range state.deps {
+			dir := filepath.Dir(dep)
+			if !watched[dir] && watcher.Add(dir) == nil {
+				watched[dir] = true
+			}
 		}
 	}
+
+	state := newWatchState(spec)
+	state.check(ch)
+	watchDeps(state)
+
+	settle := time.NewTimer(fsEventSettle)
+	settle.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Clean(event.Name)
+			if spec.recursive && event.Has(fsnotify.Create) {
+				if info, err := os.Stat(name); err == nil && info.IsDir() {
+					// A directory removed right after being created is not
+					// worth reporting.
+					if err := addWatchDirs(watcher, spec, name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+						ch <- watchErrMsg{Err: err}
+					}
+					settle.Reset(fsEventSettle)
+					continue
+				}
+			}
+			if spec.matchesEvent(name) || state.deps[name] || filepath.Base(name) == ".gitignore" {
+				settle.Reset(fsEventSettle)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// Events were dropped; re-check instead of reporting.
+				settle.Reset(fsEventSettle)
+				continue
+			}
+			ch <- watchErrMsg{Err: err}
+		case <-settle.C:
+			state.check(ch)
+			watchDeps(state)
+		}
+	}
+}
+
+// matchesEvent reports whether a change to path may affect the watch target.
+func (spec watchSpec) matchesEvent(path string) bool {
+	if spec.mode == watchModeFile {
+		return filepath.Clean(path) == spec.filePath
+	}
+	return spec.matchesName(filepath.Base(path))
 }
 
 func readWatcherUpdateCmd(ch <-chan tea.Msg) tea.Cmd {
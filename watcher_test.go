@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestWatchEventsReportsAtomicSave(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.cpp")
+	if err := os.WriteFile(source, []byte("int main() {}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+
+	spec, err := parseWatchSpec(source)
+	if err != nil {
+		t.Fatalf("parseWatchSpec returned error: %v", err)
+	}
+	watcher, err := newFSWatcher(spec)
+	if err != nil {
+		t.Skipf("file system notifications unavailable: %v", err)
+	}
+	t.Cleanup(func() { watcher.Close() })
+
+	ch := make(chan tea.Msg, 16)
+	go watchEvents(spec, watcher, ch)
+
+	if files, ok := nextWatchMsg(t, ch).(watchFilesMsg); !ok || len(files.Paths) != 1 {
+		t.Fatalf("expected the target list first, got %#v", files)
+	}
+	if msg := nextWatchMsg(t, ch); !msg.(watchEventMsg).Initial {
+		t.Fatalf("expected the initial event first, got %#v", msg)
+	}
+
+	// Mimic an editor saving through a temporary file and a rename.
+	tmp := filepath.Join(dir, ".a.cpp.swp")
+	if err := os.WriteFile(tmp, []byte("int main() { return 0; }\n"), 0o644); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(tmp, later, later); err != nil {
+		t.Fatalf("failed to touch temp file: %v", err)
+	}
+	if err := os.Rename(tmp, source); err != nil {
+		t.Fatalf("failed to rename temp file: %v", err)
+	}
+
+	msg, ok := nextWatchMsg(t, ch).(watchEventMsg)
+	if !ok || msg.Initial || msg.Path != spec.filePath {
+		t.Fatalf("expected a change event for %q, got %#v", spec.filePath, msg)
+	}
+
+	select {
+	case extra := <-ch:
+		t.Fatalf("expected the save burst to coalesce, got extra %#v", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func nextWatchMsg(t *testing.T, ch <-chan tea.Msg) tea.Msg {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for a watcher message")
+		return nil
+	}
+}
+
+func TestLatestTargetRecursiveWithIgnoresAndIncludes(t *testing.T) {
+	root := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	write := func(rel, content string, age time.Duration) string {
+		t.Helper()
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", rel, err)
+		}
+		modTime := base.Add(age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to touch %s: %v", rel, err)
+		}
+		return path
+	}
+
+	write(".gitignore", "build/\n*.gen.cpp\n", 0)
+	solution := write("contest/round-42/a.cpp", "#include \"../../lib/segtree.hpp\"\nint main() {}\n", time.Minute)
+	write("contest/round-42/b.cpp", "int main() {}\n", 2*time.Minute)
+	write("build/newest.cpp", "int main() {}\n", time.Hour)
+	write("contest/newest.gen.cpp", "int main() {}\n", time.Hour)
+	write("scratch/skip.cpp", "int main() {}\n", time.Hour)
+	header := write("lib/segtree.hpp", "#pragma once\n", 0)
+
+	spec, err := parseWatchSpec(filepath.Join(root, "**", "*.cpp"))
+	if err != nil {
+		t.Fatalf("parseWatchSpec returned error: %v", err)
+	}
+	if !spec.recursive || spec.dir != root {
+		t.Fatalf("expected a recursive spec rooted at %q, got %+v", root, spec)
+	}
+	spec.ignore = []string{"scratch/"}
+
+	cache := newIncludeCache()
+	latest, err := latestTarget(spec, cache)
+	if err != nil {
+		t.Fatalf("latestTarget returned error: %v", err)
+	}
+	if filepath.Base(latest.Path) != "b.cpp" {
+		t.Fatalf("expected b.cpp to be the latest target, got %q", latest.Path)
+	}
+
+	// Editing the shared header makes the solution including it the latest.
+	later := base.Add(3 * time.Minute)
+	if err := os.Chtimes(header, later, later); err != nil {
+		t.Fatalf("failed to touch header: %v", err)
+	}
+	latest, err = latestTarget(spec, cache)
+	if err != nil {
+		t.Fatalf("latestTarget returned error: %v", err)
+	}
+	if latest.Path != solution || !latest.ModTime.Equal(later) {
+		t.Fatalf("expected %q at %s, got %+v", solution, later, latest)
+	}
+	if len(latest.Deps) != 1 || latest.Deps[0] != header {
+		t.Fatalf("expected the header as dependency, got %v", latest.Deps)
+	}
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,10 +23,9 @@ var defaultCompileFlags = map[string][]string{
 	".cpp": {"-std=c++11"},
 }
 
-const (
-	compiledBinary    = "defitestprogram"
-	compiledBinaryWin = "defitestprogram.exe"
-)
+// compiledBinary is the name of the solution binary in a run's build
+// directory.
+const compiledBinary = "defitestprogram"
 
 type phaseMsg struct {
 	Name      string
@@ -35,6 +36,9 @@ type phaseMsg struct {
 
 type testsInitMsg struct {
 	Total int
+	// Cases lists the indexes of the cases about to run when only some of
+	// them run; the others keep their previous results.
+	Cases []int
 }
 
 type testStatus string
@@ -56,6 +60,11 @@ type testStatusMsg struct {
 	Inputs           []string
 	ExpectedOutput   string
 	ActualOutput     string
+	// Duration is how long the solution ran on the case.
+	Duration time.Duration
+	// Regression flags a case that did better in the previous run, see
+	// historyRecorder.
+	Regression string
 }
 
 type testsDoneMsg struct {
@@ -64,13 +73,85 @@ type testsDoneMsg struct {
 	Err    error
 }
 
-func runWorkflow(sourcePath string, overrideFlags []string, send func(tea.Msg)) (int, int, error) {
+// outputsRecordedMsg reports how many pending cases had their output written back.
+type outputsRecordedMsg struct {
+	Path  string
+	Count int
+}
+
+// runOptions tunes a single workflow run.
+type runOptions struct {
+	// compileFlags overrides the per-language default compiler flags.
+	compileFlags []string
+	// record captures the actual output of pending cases into the source file.
+	record bool
+	// timeLimit applies to every case unless the source declares its own.
+	timeLimit time.Duration
+	// checker names how outputs are compared, see newChecker.
+	checker string
+	// noDelay skips the pauses that pace the TUI animation.
+	noDelay bool
+	// history, when set, records the run and flags regressions.
+	history *historyStore
+	// cases, when set, runs only the cases at these zero-based indexes.
+	cases []int
+	// casesTotal is the number of cases the indexes in cases refer to. When
+	// the source has a different number of cases by now, every case runs.
+	casesTotal int
+}
+
+// pause waits d so progress stays readable in the TUI.
+func (o runOptions) pause(d time.Duration) {
+	if !o.noDelay {
+		time.Sleep(d)
+	}
+}
+
+// errRunCanceled is returned when a run is abandoned for a newer one.
+var errRunCanceled = errors.New("run canceled")
+
+// errTimeLimitExceeded is returned when a program outlives its time limit.
+var errTimeLimitExceeded = errors.New("time limit exceeded")
+
+// runWorkflow compiles and tests sourcePath, stopping early and killing any
+// compiler or solution process once ctx is canceled. Finished runs of every
+// case are saved to opts.history.
+func runWorkflow(ctx context.Context, sourcePath string, opts runOptions, send func(tea.Msg)) (int, int, error) {
+	// A partial run says nothing about the file as a whole, so it would
+	// mislead the regression and last-pass comparisons.
+	if opts.history == nil || opts.cases != nil {
+		return runSuite(ctx, sourcePath, opts, send)
+	}
+
+	recorder := newHistoryRecorder(opts.history, sourcePath)
+	passed, total, err := runSuite(ctx, sourcePath, opts, func(msg tea.Msg) {
+		send(recorder.observe(msg))
+	})
+	if !errors.Is(err, errRunCanceled) {
+		send(recorder.finish(passed, total, err))
+	}
+	return passed, total, err
+}
+
+// runSuite runs the cases of sourcePath selected by opts.cases, reporting
+// progress through send. The totals it returns cover the cases that ran.
+func runSuite(ctx context.Context, sourcePath string, opts runOptions, send func(tea.Msg)) (int, int, error) {
 	var (
 		compiler     string
 		cases        []PromptCase
 		total        int
 		defaultFlags []string
+		limits       PromptLimits
+		checker      outputChecker = exactChecker{}
+		// buildDir holds the binaries of this run only, so a compiler left
+		// over from a canceled run cannot overwrite them.
+		buildDir string
 	)
+	defer func() {
+		if buildDir != "" {
+			os.RemoveAll(buildDir)
+		}
+	}()
 
 	phases := []struct {
 		name string
@@ -104,28 +185,50 @@ func runWorkflow(sourcePath string, overrideFlags []string, send func(tea.Msg))
 			},
 		},
 		{
-			name: "🧹 Cleaning previous build",
-			fn:   removeExistingBinaries,
+			name: "🧹 Preparing build directory",
+			fn: func() error {
+				var err error
+				buildDir, err = os.MkdirTemp("", "defi-build-")
+				return err
+			},
 		},
 		{
 			name: "🛠️ Compiling",
 			fn: func() error {
 				flags := defaultFlags
-				if len(overrideFlags) > 0 {
-					flags = overrideFlags
+				if len(opts.compileFlags) > 0 {
+					flags = opts.compileFlags
+				}
+				if err := compileSource(ctx, sourcePath, compiler, flags, filepath.Join(buildDir, compiledBinary)); err != nil {
+					return err
+				}
+				if opts.checker == "" {
+					return nil
 				}
-				return compileSource(sourcePath, compiler, flags)
+
+				var err error
+				checker, err = newChecker(ctx, opts.checker, buildDir)
+				return err
 			},
 		},
 		{
 			name: "📝 Parsing prompts",
 			fn: func() error {
-				parsed, err := NewPromptParser(sourcePath).Parse()
+				parsed, err := loadTestSuite(sourcePath)
 				if err != nil {
 					return err
 				}
 				cases = parsed
 				total = len(parsed)
+
+				limits, err = loadPromptLimits(sourcePath)
+				if err != nil {
+					return err
+				}
+				if limits.Time == 0 {
+					limits.Time = opts.timeLimit
+				}
+				limits = limits.withCompileFlags(opts.compileFlags)
 				return nil
 			},
 		},
@@ -133,20 +236,46 @@ func runWorkflow(sourcePath string, overrideFlags []string, send func(tea.Msg))
 
 	for i, phase := range phases {
 		send(phaseMsg{Name: phase.name, Index: i + 1, Total: len(phases)})
-		time.Sleep(time.Millisecond * 100) // Simulate some delay for better UX
+		opts.pause(time.Millisecond * 100)
+		if ctx.Err() != nil {
+			return 0, total, errRunCanceled
+		}
 		if err := phase.fn(); err != nil {
+			if ctx.Err() != nil {
+				return 0, total, errRunCanceled
+			}
 			return 0, total, err
 		}
 		send(phaseMsg{Name: phase.name, Index: i + 1, Total: len(phases), Completed: true})
 	}
 
-	send(testsInitMsg{Total: total})
+	if opts.cases != nil && total != opts.casesTotal {
+		opts.cases = nil
+	}
+	send(testsInitMsg{Total: total, Cases: opts.cases})
+
+	ran := total
+	if opts.cases != nil {
+		ran = 0
+		for idx := range cases {
+			if slices.Contains(opts.cases, idx) {
+				ran++
+			}
+		}
+	}
 
 	passed := 0
 	var firstErr error
+	recorded := make(map[int][]string)
 
 	for idx, c := range cases {
-		time.Sleep(time.Millisecond * 100) // Simulate some delay for better UX
+		if opts.cases != nil && !slices.Contains(opts.cases, idx) {
+			continue
+		}
+		opts.pause(time.Millisecond * 100)
+		if ctx.Err() != nil {
+			return passed, ran, errRunCanceled
+		}
 		send(testStatusMsg{
 			Current:        idx + 1,
 			Total:          total,
@@ -156,8 +285,12 @@ func runWorkflow(sourcePath string, overrideFlags []string, send func(tea.Msg))
 			ExpectedOutput: strings.Join(c.Outputs, "\n"),
 		})
 
-		outputs, err := runSingleCase(idx, c)
-		time.Sleep(time.Millisecond * 200) // Simulate some delay for better UX
+		run, err := runSingleCase(ctx, filepath.Join(buildDir, compiledBinary), idx, c, limits)
+		outputs := run.Outputs
+		opts.pause(time.Millisecond * 200)
+		if ctx.Err() != nil {
+			return passed, ran, errRunCanceled
+		}
 		if err != nil {
 			if firstErr == nil {
 				firstErr = err
@@ -177,7 +310,30 @@ func runWorkflow(sourcePath string, overrideFlags []string, send func(tea.Msg))
 			continue
 		}
 
-		if err := compareOutputs(c.Outputs, outputs); err != nil {
+		if c.Pending {
+			if !opts.record {
+				err := fmt.Errorf("case %d: no expected output, record it with --record", idx+1)
+				if firstErr == nil {
+					firstErr = err
+				}
+				send(testStatusMsg{
+					Current:          idx + 1,
+					Total:            total,
+					Passed:           passed,
+					Status:           testStatusFailed,
+					CompileSuccess:   true,
+					AssertionSuccess: false,
+					Err:              err,
+					Inputs:           c.Inputs,
+					ActualOutput:     strings.Join(outputs, "\n"),
+				})
+				continue
+			}
+			recorded[idx] = outputs
+			c.Outputs = outputs
+		}
+
+		if err := checker.Check(c.Inputs, c.Outputs, outputs); err != nil {
 			wrapped := fmt.Errorf("case %d: %w", idx+1, err)
 			if firstErr == nil {
 				firstErr = wrapped
@@ -193,6 +349,7 @@ func runWorkflow(sourcePath string, overrideFlags []string, send func(tea.Msg))
 				Inputs:           c.Inputs,
 				ExpectedOutput:   strings.Join(c.Outputs, "\n"),
 				ActualOutput:     strings.Join(outputs, "\n"),
+				Duration:         run.Wall,
 			})
 			continue
 		}
@@ -208,17 +365,28 @@ func runWorkflow(sourcePath string, overrideFlags []string, send func(tea.Msg))
 			Inputs:           c.Inputs,
 			ExpectedOutput:   strings.Join(c.Outputs, "\n"),
 			ActualOutput:     strings.Join(outputs, "\n"),
+			Duration:         run.Wall,
 		})
 	}
-	time.Sleep(time.Millisecond * 300) // Simulate some delay for better UX
+	opts.pause(time.Millisecond * 300)
+	if ctx.Err() != nil {
+		return passed, ran, errRunCanceled
+	}
 
-	return passed, total, firstErr
+	if len(recorded) > 0 {
+		if err := writeCaseOutputs(sourcePath, recorded); err != nil {
+			return passed, ran, fmt.Errorf("failed to record outputs: %w", err)
+		}
+		send(outputsRecordedMsg{Path: sourcePath, Count: len(recorded)})
+	}
+
+	return passed, ran, firstErr
 }
 
-func compileSource(sourcePath, compiler string, flags []string) error {
+func compileSource(ctx context.Context, sourcePath, compiler string, flags []string, output string) error {
 	args := append([]string{}, flags...)
-	args = append(args, sourcePath, "-o", compiledBinary)
-	cmd := exec.Command(compiler, args...)
+	args = append(args, sourcePath, "-o", output)
+	cmd := exec.CommandContext(ctx, compiler, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
@@ -227,71 +395,140 @@ func compileSource(sourcePath, compiler string, flags []string) error {
 	return nil
 }
 
-func removeExistingBinaries() error {
-	if err := removeIfExists(compiledBinary); err != nil {
-		return err
+// prepareProgram compiles a supported source into dir/name and returns the
+// binary path. Other files are used as they are, so scripts with a shebang
+// work too. compileFlags overrides the language defaults when set.
+func prepareProgram(ctx context.Context, path, dir, name string, compileFlags []string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to access %q: %w", path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%q is a directory, expected a file", path)
 	}
 
-	if err := removeIfExists(compiledBinaryWin); err != nil {
-		return err
+	ext := filepath.Ext(path)
+	compiler, ok := supportedLanguages[ext]
+	if !ok {
+		if info.Mode()&0o111 == 0 {
+			return "", fmt.Errorf("%q is neither a supported source nor executable", path)
+		}
+		return filepath.Abs(path)
+	}
+	if _, err := exec.LookPath(compiler); err != nil {
+		return "", fmt.Errorf("required compiler %q not found in PATH: %w", compiler, err)
 	}
 
-	return nil
+	flags := defaultCompileFlags[ext]
+	if len(compileFlags) > 0 {
+		flags = compileFlags
+	}
+	output := filepath.Join(dir, name)
+	if err := compileSource(ctx, path, compiler, flags, output); err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return filepath.Abs(output)
 }
 
-func removeIfExists(path string) error {
-	err := os.Remove(path)
-	if err == nil || errors.Is(err, os.ErrNotExist) {
-		return nil
+func runSingleCase(ctx context.Context, binary string, idx int, c PromptCase, limits PromptLimits) (programRun, error) {
+	run, err := runProgram(ctx, binary, nil, c.Inputs, limits)
+	if err != nil {
+		return programRun{}, fmt.Errorf("case %d: %w", idx+1, err)
 	}
-	return fmt.Errorf("unable to delete %q: %w", path, err)
+	return run, nil
+}
+
+// programRun is the outcome of a successful program execution.
+type programRun struct {
+	Outputs []string
+	// Wall is the elapsed time; CPU adds the user and system time.
+	Wall time.Duration
+	CPU  time.Duration
+}
+
+// execProgram runs path with args, feeding inputs on stdin, and returns its
+// stdout lines. The time and memory limits apply when set.
+func execProgram(ctx context.Context, path string, args []string, inputs []string, limits PromptLimits) ([]string, error) {
+	run, err := runProgram(ctx, path, args, inputs, limits)
+	return run.Outputs, err
 }
 
-func runSingleCase(idx int, c PromptCase) ([]string, error) {
-	cmd := exec.Command("./" + compiledBinary)
+// runProgram is execProgram reporting how long the program took.
+func runProgram(ctx context.Context, path string, args []string, inputs []string, limits PromptLimits) (programRun, error) {
+	if limits.Time > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Time)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	if limits.MemoryMB > 0 {
+		cmd = memoryLimitedCommand(ctx, path, args, limits.MemoryMB)
+	}
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		return nil, fmt.Errorf("case %d: failed to obtain stdin: %w", idx+1, err)
+		return programRun{}, fmt.Errorf("failed to obtain stdin: %w", err)
 	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		stdin.Close()
-		return nil, fmt.Errorf("case %d: failed to obtain stdout: %w", idx+1, err)
+		return programRun{}, fmt.Errorf("failed to obtain stdout: %w", err)
 	}
 
 	cmd.Stderr = os.Stderr
 
+	started := time.Now()
 	if err := cmd.Start(); err != nil {
 		stdin.Close()
-		return nil, fmt.Errorf("case %d: start failed: %w", idx+1, err)
+		return programRun{}, fmt.Errorf("start failed: %w", err)
 	}
 
-	for _, line := range c.Inputs {
-		if _, err := fmt.Fprintln(stdin, line); err != nil {
-			stdin.Close()
-			cmd.Wait()
-			return nil, fmt.Errorf("case %d: failed to write input: %w", idx+1, err)
+	// Feed stdin concurrently so programs answering as they read cannot
+	// block on a full stdout pipe.
+	written := make(chan error, 1)
+	go func() {
+		w := bufio.NewWriter(stdin)
+		var err error
+		for _, line := range inputs {
+			if _, err = fmt.Fprintln(w, line); err != nil {
+				break
+			}
 		}
-	}
-	stdin.Close()
+		if err == nil {
+			err = w.Flush()
+		}
+		stdin.Close()
+		written <- err
+	}()
 
 	var outputs []string
 	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
 	for scanner.Scan() {
 		outputs = append(outputs, strings.TrimRight(scanner.Text(), "\r"))
 	}
 
 	if err := scanner.Err(); err != nil {
 		cmd.Wait()
-		return nil, fmt.Errorf("case %d: failed to read stdout: %w", idx+1, err)
+		return programRun{}, fmt.Errorf("failed to read stdout: %w", err)
 	}
 
 	if err := cmd.Wait(); err != nil {
-		return nil, fmt.Errorf("case %d: execution failed: %w", idx+1, err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return programRun{}, fmt.Errorf("%w (%s)", errTimeLimitExceeded, limits.Time)
+		}
+		return programRun{}, fmt.Errorf("execution failed: %w", err)
+	}
+	if err := <-written; err != nil {
+		return programRun{}, fmt.Errorf("failed to write input: %w", err)
 	}
 
-	return outputs, nil
+	return programRun{
+		Outputs: outputs,
+		Wall:    time.Since(started),
+		CPU:     cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime(),
+	}, nil
 }
 
 func compareOutputs(expected, actual []string) error {